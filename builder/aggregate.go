@@ -0,0 +1,263 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	errNoAggregate     = errors.New("[builder] at least one aggregate is required")
+	errAggregateFilter = errors.New(`[builder] the where of AggregateFilter can't contain "_orderby", "_groupby", "_having" or "_limit"`)
+	errFilterSymble    = errors.New("[builder] AggregateFilter can't be rendered by Symble(), use BuildAggregate")
+)
+
+// groupKeySeparator joins the values of a multi-column GroupKey
+const groupKeySeparator = "\x00"
+
+// GroupKey identifies a group of AggregateGroupQuery by the kinds and the text forms of its group-by values
+type GroupKey string
+
+// NewGroupKey returns the GroupKey of the group whose group-by columns equal vals, in the order of groupBy.
+// a value is told apart by its kind besides its text, so NULL(nil) isn't "" and 1 isn't "1",
+// but []byte and string are both text and the integers of any size are the same
+func NewGroupKey(vals ...interface{}) GroupKey {
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		strs[i] = groupKeyKind(v) + ":" + (&resultResolve{data: v}).String()
+	}
+	return GroupKey(strings.Join(strs, groupKeySeparator))
+}
+
+func groupKeyKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case []byte, string:
+		return "text"
+	case bool:
+		return "bool"
+	case float32, float64:
+		return "float"
+	case time.Time:
+		return "time"
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Resolvers holds the results of a group, keyed by the group-by column names
+// and the names of the aggregates, which are the aliases given by AggregateAs or Symble() otherwise
+type Resolvers map[string]ResultResolver
+
+// aggregateAppender is implemented by the aggregates carrying values, which take placeholders
+type aggregateAppender interface {
+	appendAggregate(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) ([]interface{}, error)
+}
+
+// namedAggregate is implemented by the aggregates having a name other than Symble()
+type namedAggregate interface {
+	aggregateName() string
+}
+
+func appendAggregate(buf *sqlBuffer, ag AggregateSymbleBuilder, placeHolderIndex *int, vals []interface{}) ([]interface{}, error) {
+	if appender, ok := ag.(aggregateAppender); ok {
+		return appender.appendAggregate(buf, placeHolderIndex, vals)
+	}
+	buf.writeString(ag.Symble())
+	return vals, nil
+}
+
+func aggregateName(ag AggregateSymbleBuilder) string {
+	if named, ok := ag.(namedAggregate); ok {
+		return named.aggregateName()
+	}
+	return ag.Symble()
+}
+
+// AggregateCountDistinct count(DISTINCT col)
+func AggregateCountDistinct(col string) AggregateSymbleBuilder {
+	return agBuilder("count(DISTINCT " + col + ")")
+}
+
+type agAlias struct {
+	ag    AggregateSymbleBuilder
+	alias string
+}
+
+// AggregateAs names the result of ag, ie: sum(amount) AS total
+func AggregateAs(ag AggregateSymbleBuilder, alias string) AggregateSymbleBuilder {
+	return agAlias{ag, alias}
+}
+
+func (a agAlias) Symble() string {
+	return a.ag.Symble() + " AS " + a.alias
+}
+
+func (a agAlias) aggregateName() string {
+	return a.alias
+}
+
+func (a agAlias) appendAggregate(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) ([]interface{}, error) {
+	vals, err := appendAggregate(buf, a.ag, placeHolderIndex, vals)
+	if nil != err {
+		return nil, err
+	}
+	buf.writeString(" AS ")
+	buf.writeString(a.alias)
+	return vals, nil
+}
+
+type agFilter struct {
+	ag    AggregateSymbleBuilder
+	where interface{}
+}
+
+// AggregateFilter only aggregates the rows matched by where, ie: sum(amount) FILTER (WHERE (status=$1)).
+// where is a map[string]interface{} or an OrderedWhere without the special keys, it's checked here.
+// the values of where can't be carried by a string, so the filter must be rendered by BuildAggregate,
+// AggregateQuery or the group queries, and its Symble() panics
+func AggregateFilter(ag AggregateSymbleBuilder, where interface{}) (AggregateSymbleBuilder, error) {
+	f := agFilter{ag, where}
+	var placeHolderIndex int
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := f.appendAggregate(buf, &placeHolderIndex, nil); nil != err {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f agFilter) Symble() string {
+	panic(errFilterSymble)
+}
+
+func (f agFilter) aggregateName() string {
+	return aggregateName(f.ag)
+}
+
+func (f agFilter) appendAggregate(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) ([]interface{}, error) {
+	special, rest, err := splitWhere(f.where)
+	if nil != err {
+		return nil, err
+	}
+	if len(special) > 0 {
+		return nil, errAggregateFilter
+	}
+	conditions, release, err := getWhereConditions(rest)
+	if nil != err {
+		return nil, err
+	}
+	defer release()
+	// an alias must come after the filter
+	inner := f.ag
+	alias, aliased := inner.(agAlias)
+	if aliased {
+		inner = alias.ag
+	}
+	if vals, err = appendAggregate(buf, inner, placeHolderIndex, vals); nil != err {
+		return nil, err
+	}
+	mark := len(buf.b)
+	vals = appendWhere(buf, " FILTER (WHERE ", placeHolderIndex, vals, conditions...)
+	if len(buf.b) != mark {
+		buf.writeByte(')')
+	}
+	if aliased {
+		buf.writeString(" AS ")
+		buf.writeString(alias.alias)
+	}
+	return vals, nil
+}
+
+// BuildAggregate builds a query selecting the groupBy columns and the aggregates,
+// grouped by the groupBy columns which replace the _groupby of where.
+// _having, _orderby and _limit of where work the same as BuildSelect
+func BuildAggregate(table string, where interface{}, groupBy []string, aggregates ...AggregateSymbleBuilder) (string, []interface{}, error) {
+	if 0 == len(aggregates) {
+		return "", nil, errNoAggregate
+	}
+	var placeHolderIndex int
+	var vals []interface{}
+	fields := make([]string, 0, len(groupBy)+len(aggregates))
+	fields = append(fields, groupBy...)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	for _, ag := range aggregates {
+		buf.b = buf.b[:0]
+		var err error
+		if vals, err = appendAggregate(buf, ag, &placeHolderIndex, vals); nil != err {
+			return "", nil, err
+		}
+		fields = append(fields, buf.String())
+	}
+	if len(groupBy) > 0 {
+		var err error
+		if where, err = withKeys(where, KV{"_groupby", strings.Join(groupBy, ",")}); nil != err {
+			return "", nil, err
+		}
+	}
+	cond, whereVals, err := selectFrom(table, where, fields, placeHolderIndex)
+	if nil != err {
+		return "", nil, err
+	}
+	return cond, append(vals, whereVals...), nil
+}
+
+// AggregateGroupQuery executes the aggregates grouped by the groupBy columns and returns the results by group,
+// use NewGroupKey to look a group up. the Resolvers of a group contains its group-by columns as well
+func AggregateGroupQuery(ctx context.Context, db Executor, table string, where interface{}, groupBy []string, aggregates ...AggregateSymbleBuilder) (map[GroupKey]Resolvers, error) {
+	cond, vals, err := BuildAggregate(table, where, groupBy, aggregates...)
+	if nil != err {
+		return nil, err
+	}
+	names := make([]string, 0, len(groupBy)+len(aggregates))
+	names = append(names, groupBy...)
+	for _, ag := range aggregates {
+		names = append(names, aggregateName(ag))
+	}
+	rows, err := db.QueryContext(ctx, cond, vals...)
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[GroupKey]Resolvers)
+	data := make([]interface{}, len(names))
+	dest := make([]interface{}, len(names))
+	for rows.Next() {
+		for i := range data {
+			data[i] = nil
+			dest[i] = &data[i]
+		}
+		if err = rows.Scan(dest...); nil != err {
+			return nil, err
+		}
+		resolvers := make(Resolvers, len(names))
+		for i, name := range names {
+			resolvers[name] = &resultResolve{data: data[i]}
+		}
+		result[NewGroupKey(data[:len(groupBy)]...)] = resolvers
+	}
+	return result, rows.Err()
+}
+
+// AggregateGroupScan is the same as AggregateGroupQuery but scans the groups into dest,
+// which must be a pointer to a slice of struct.
+// the columns are matched by name, so name the aggregates with AggregateAs
+func AggregateGroupScan(ctx context.Context, db Executor, table string, where interface{}, groupBy []string, dest interface{}, aggregates ...AggregateSymbleBuilder) error {
+	if err := checkPageTarget(dest); nil != err {
+		return err
+	}
+	cond, vals, err := BuildAggregate(table, where, groupBy, aggregates...)
+	if nil != err {
+		return err
+	}
+	return queryScan(ctx, db, cond, vals, dest)
+}
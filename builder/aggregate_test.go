@@ -0,0 +1,173 @@
+package builder
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func mustFilter(ag AggregateSymbleBuilder, where interface{}) AggregateSymbleBuilder {
+	f, err := AggregateFilter(ag, where)
+	if nil != err {
+		panic(err)
+	}
+	return f
+}
+
+func TestBuildAggregate(t *testing.T) {
+	var data = []struct {
+		where      interface{}
+		groupBy    []string
+		aggregates []AggregateSymbleBuilder
+		cond       string
+		vals       []interface{}
+		err        error
+	}{
+		{
+			where:   map[string]interface{}{"age >": 10, "_orderby": "region asc"},
+			groupBy: []string{"region"},
+			aggregates: []AggregateSymbleBuilder{
+				AggregateCount("*"),
+				AggregateAs(AggregateSum("amount"), "total"),
+				AggregateMax("created_at"),
+			},
+			cond: "SELECT region,count(*),sum(amount) AS total,max(created_at) FROM tb WHERE (age>$1) GROUP BY region ORDER BY region ASC",
+			vals: []interface{}{10},
+		},
+		{
+			where:   OrderedWhere{{"age >", 10}, {"_having", map[string]interface{}{"count(*) >": 5}}},
+			groupBy: []string{"region", "city"},
+			aggregates: []AggregateSymbleBuilder{
+				AggregateAs(mustFilter(AggregateSum("amount"), map[string]interface{}{"status in": []string{"paid", "done"}}), "paid"),
+				mustFilter(AggregateAs(AggregateCountDistinct("user_id"), "users"), OrderedWhere{{"vip", true}}),
+			},
+			cond: "SELECT region,city,sum(amount) FILTER (WHERE (status IN ($1,$2))) AS paid,count(DISTINCT user_id) FILTER (WHERE (vip=$3)) AS users FROM tb WHERE (age>$4) GROUP BY region,city HAVING (count(*)>$5)",
+			vals: []interface{}{"paid", "done", true, 10, 5},
+		},
+		{
+			aggregates: []AggregateSymbleBuilder{mustFilter(AggregateCount("*"), nil)},
+			cond:       "SELECT count(*) FROM tb",
+		},
+		{
+			err: errNoAggregate,
+		},
+	}
+	ass := assert.New(t)
+	for idx, tc := range data {
+		cond, vals, err := BuildAggregate("tb", tc.where, tc.groupBy, tc.aggregates...)
+		ass.Equal(tc.err, err, "idx:%d", idx)
+		ass.Equal(tc.cond, cond, "idx:%d", idx)
+		ass.Equal(tc.vals, vals, "idx:%d", idx)
+	}
+}
+
+func TestAggregateFilter(t *testing.T) {
+	ass := assert.New(t)
+	ag, err := AggregateFilter(AggregateSum("amount"), map[string]interface{}{"status": "paid"})
+	ass.NoError(err)
+	ass.Equal("sum(amount)", aggregateName(ag))
+	ass.Equal("total", aggregateName(AggregateAs(ag, "total")))
+	ass.Equal("sum(amount) AS total", AggregateAs(AggregateSum("amount"), "total").Symble())
+	ass.PanicsWithValue(errFilterSymble, func() { _ = ag.Symble() })
+	ass.PanicsWithValue(errFilterSymble, func() { _ = AggregateAs(ag, "total").Symble() })
+
+	cond, vals, err := BuildAggregate("tb", map[string]interface{}{"b": 2}, nil, ag)
+	ass.NoError(err)
+	ass.Equal("SELECT sum(amount) FILTER (WHERE (status=$1)) FROM tb WHERE (b=$2)", cond)
+	ass.Equal([]interface{}{"paid", 2}, vals)
+
+	_, err = AggregateFilter(AggregateCount("*"), map[string]interface{}{"_limit": []uint{1, 0}})
+	ass.Equal(errAggregateFilter, err)
+	_, err = AggregateFilter(AggregateCount("*"), map[string]interface{}{"age ~": 1})
+	ass.Equal(ErrUnsupportedOperator, err)
+	_, err = AggregateFilter(AggregateSum("amount"), 1)
+	ass.Equal(errWhereType, err)
+}
+
+func TestNewGroupKey(t *testing.T) {
+	ass := assert.New(t)
+	ass.NotEqual(NewGroupKey(nil), NewGroupKey(""))
+	ass.NotEqual(NewGroupKey(int64(1)), NewGroupKey("1"))
+	ass.NotEqual(NewGroupKey(true), NewGroupKey("true"))
+	ass.Equal(NewGroupKey("a"), NewGroupKey([]byte("a")))
+	ass.Equal(NewGroupKey(1), NewGroupKey(int64(1)))
+	ass.NotEqual(NewGroupKey("north", "Harbin"), NewGroupKey("northHarbin"))
+}
+
+func TestAggregateGroupQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT region,count(*),sum(amount) AS total FROM tb WHERE (age>$1) GROUP BY region")).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"region", "count", "total"}).
+			AddRow([]byte("north"), int64(3), []byte("10.50")).
+			AddRow([]byte("south"), int64(1), nil))
+	result, err := AggregateGroupQuery(ctx, db, "tb", map[string]interface{}{"age >": 10}, []string{"region"},
+		AggregateCount("*"), AggregateAs(AggregateSum("amount"), "total"))
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Len(result, 2)
+	north := result[NewGroupKey("north")]
+	ass.Equal("north", north["region"].String())
+	ass.Equal(int64(3), north["count(*)"].Int64())
+	ass.Equal(10.5, north["total"].Float64())
+	ass.True(result[NewGroupKey([]byte("south"))]["total"].IsNull())
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT region,city,count(*) AS cnt FROM tb GROUP BY region,city")).
+		WillReturnRows(sqlmock.NewRows([]string{"region", "city", "cnt"}).
+			AddRow("north", "Beijing", int64(2)).
+			AddRow("north", "Harbin", int64(5)))
+	result, err = AggregateGroupQuery(ctx, db, "tb", nil, []string{"region", "city"}, AggregateAs(AggregateCount("*"), "cnt"))
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal(int64(5), result[NewGroupKey("north", "Harbin")]["cnt"].Int64())
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT region,count(*) FROM tb GROUP BY region")).
+		WillReturnRows(sqlmock.NewRows([]string{"region", "count"}).
+			AddRow(nil, int64(2)).
+			AddRow([]byte(""), int64(3)))
+	result, err = AggregateGroupQuery(ctx, db, "tb", nil, []string{"region"}, AggregateCount("*"))
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Len(result, 2)
+	ass.Equal(int64(2), result[NewGroupKey(nil)]["count(*)"].Int64())
+	ass.Equal(int64(3), result[NewGroupKey("")]["count(*)"].Int64())
+
+	_, err = AggregateGroupQuery(ctx, db, "tb", nil, []string{"region"})
+	ass.Equal(errNoAggregate, err)
+}
+
+func TestAggregateGroupScan(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	type regionStat struct {
+		Region string  `ddb:"region"`
+		Users  int64   `ddb:"users"`
+		Total  float64 `ddb:"total"`
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT region,count(DISTINCT user_id) AS users,sum(amount) AS total FROM tb GROUP BY region")).
+		WillReturnRows(sqlmock.NewRows([]string{"region", "users", "total"}).AddRow("north", int64(2), 10.5))
+	var stats []regionStat
+	err = AggregateGroupScan(context.Background(), db, "tb", nil, []string{"region"}, &stats,
+		AggregateAs(AggregateCountDistinct("user_id"), "users"), AggregateAs(AggregateSum("amount"), "total"))
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal([]regionStat{{"north", 2, 10.5}}, stats)
+
+	err = AggregateGroupScan(context.Background(), db, "tb", nil, []string{"region"}, stats, AggregateCount("*"))
+	ass.Equal(errPaginateTarget, err)
+}
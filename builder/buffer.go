@@ -0,0 +1,103 @@
+package builder
+
+import (
+	"strconv"
+	"sync"
+)
+
+// buffers grown beyond this size are dropped instead of being put back to the pool
+const maxPooledBufferSize = 64 << 10
+
+// sqlBuffer is a reusable buffer which statements are rendered into
+type sqlBuffer struct {
+	b []byte
+	// number of conditions written into the current where group
+	n int
+	// scratch space for sorting the keys of a where map
+	keys []string
+}
+
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		return &sqlBuffer{b: make([]byte, 0, 256)}
+	},
+}
+
+func getBuffer() *sqlBuffer {
+	buf := bufPool.Get().(*sqlBuffer)
+	buf.b = buf.b[:0]
+	buf.n = 0
+	return buf
+}
+
+func putBuffer(buf *sqlBuffer) {
+	if cap(buf.b) > maxPooledBufferSize {
+		return
+	}
+	for i := range buf.keys {
+		buf.keys[i] = ""
+	}
+	buf.keys = buf.keys[:0]
+	bufPool.Put(buf)
+}
+
+func (buf *sqlBuffer) String() string {
+	return string(buf.b)
+}
+
+func (buf *sqlBuffer) writeString(s string) {
+	buf.b = append(buf.b, s...)
+}
+
+func (buf *sqlBuffer) writeByte(c byte) {
+	buf.b = append(buf.b, c)
+}
+
+func (buf *sqlBuffer) writeUint(u uint64) {
+	buf.b = strconv.AppendUint(buf.b, u, 10)
+}
+
+// writePlaceholder increases the index and writes $index
+func (buf *sqlBuffer) writePlaceholder(placeHolderIndex *int) {
+	*placeHolderIndex++
+	buf.b = append(buf.b, '$')
+	buf.b = strconv.AppendInt(buf.b, int64(*placeHolderIndex), 10)
+}
+
+// writePlaceholders writes ($n,$n+1,...) with num placeholders
+func (buf *sqlBuffer) writePlaceholders(num int, placeHolderIndex *int) {
+	buf.b = append(buf.b, '(')
+	for i := 0; i < num; i++ {
+		if i > 0 {
+			buf.b = append(buf.b, ',')
+		}
+		buf.writePlaceholder(placeHolderIndex)
+	}
+	buf.b = append(buf.b, ')')
+}
+
+// and starts a new condition in the current where group
+func (buf *sqlBuffer) and() {
+	if buf.n > 0 {
+		buf.b = append(buf.b, " AND "...)
+	}
+	buf.n++
+}
+
+func (buf *sqlBuffer) sortedKeys(m map[string]interface{}) []string {
+	buf.keys = buf.keys[:0]
+	for k := range m {
+		buf.keys = append(buf.keys, k)
+	}
+	defaultSortAlgorithm(buf.keys)
+	return buf.keys
+}
+
+func (buf *sqlBuffer) sortedSliceKeys(m map[string][]interface{}) []string {
+	buf.keys = buf.keys[:0]
+	for k := range m {
+		buf.keys = append(buf.keys, k)
+	}
+	defaultSortAlgorithm(buf.keys)
+	return buf.keys
+}
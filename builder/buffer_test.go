@@ -0,0 +1,337 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the legacy* functions below are the fmt.Sprintf based renderers the buffer based ones replaced,
+// they're kept to make sure the output doesn't change and to benchmark against
+
+func legacyBuild(m map[string]interface{}, op string, placeHolderIndex *int) ([]string, []interface{}) {
+	if nil == m || 0 == len(m) {
+		return nil, nil
+	}
+	length := len(m)
+	cond := make([]string, length)
+	vals := make([]interface{}, length)
+	var i int
+	for key := range m {
+		cond[i] = key
+		i++
+	}
+	defaultSortAlgorithm(cond)
+	for i = 0; i < length; i++ {
+		vals[i] = m[cond[i]]
+		*placeHolderIndex++
+		cond[i] = quoteField(cond[i]) + op + "$" + fmt.Sprintf("%d", *placeHolderIndex)
+	}
+	return cond, vals
+}
+
+func legacyBuildIn(i In, placeHolderIndex *int) ([]string, []interface{}) {
+	if nil == i || 0 == len(i) {
+		return nil, nil
+	}
+	var cond []string
+	var vals []interface{}
+	for k := range i {
+		cond = append(cond, k)
+	}
+	defaultSortAlgorithm(cond)
+	for j := 0; j < len(cond); j++ {
+		val := i[cond[j]]
+		var holders string
+		for n := 0; n < len(val); n++ {
+			*placeHolderIndex++
+			holders += fmt.Sprintf("$%d", *placeHolderIndex)
+			if n != len(val)-1 {
+				holders += ","
+			}
+		}
+		cond[j] = fmt.Sprintf("%s IN (%s)", quoteField(cond[j]), holders)
+		vals = append(vals, val...)
+	}
+	return cond, vals
+}
+
+func legacyBuildLike(l Like, placeHolderIndex *int) ([]string, []interface{}) {
+	if nil == l || 0 == len(l) {
+		return nil, nil
+	}
+	var cond []string
+	var vals []interface{}
+	for k := range l {
+		cond = append(cond, k)
+	}
+	defaultSortAlgorithm(cond)
+	for j := 0; j < len(cond); j++ {
+		val := l[cond[j]]
+		*placeHolderIndex++
+		cond[j] = cond[j] + " LIKE $" + fmt.Sprintf("%d", *placeHolderIndex)
+		vals = append(vals, val)
+	}
+	return cond, vals
+}
+
+func legacyConditionBuild(c Comparable, placeHolderIndex *int) ([]string, []interface{}) {
+	switch v := c.(type) {
+	case Eq:
+		return legacyBuild(v, "=", placeHolderIndex)
+	case Ne:
+		return legacyBuild(v, "!=", placeHolderIndex)
+	case Lt:
+		return legacyBuild(v, "<", placeHolderIndex)
+	case Lte:
+		return legacyBuild(v, "<=", placeHolderIndex)
+	case Gt:
+		return legacyBuild(v, ">", placeHolderIndex)
+	case Gte:
+		return legacyBuild(v, ">=", placeHolderIndex)
+	case In:
+		return legacyBuildIn(v, placeHolderIndex)
+	case Like:
+		return legacyBuildLike(v, placeHolderIndex)
+	}
+	return c.Build(placeHolderIndex)
+}
+
+func legacyWhereConnector(placeHolderIndex *int, conditions ...Comparable) (string, []interface{}) {
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	var where []string
+	var values []interface{}
+	for _, cond := range conditions {
+		cons, vals := legacyConditionBuild(cond, placeHolderIndex)
+		if nil == cons {
+			continue
+		}
+		where = append(where, cons...)
+		values = append(values, vals...)
+	}
+	if 0 == len(where) {
+		return "", nil
+	}
+	return "(" + strings.Join(where, " AND ") + ")", values
+}
+
+func legacyOrderBy(orderMap []eleOrderBy) (string, error) {
+	var orders []string
+	for _, orderInfo := range orderMap {
+		realOrder := strings.ToUpper(orderInfo.order)
+		if realOrder != "ASC" && realOrder != "DESC" {
+			return "", errOrderByParam
+		}
+		orders = append(orders, fmt.Sprintf("%s %s", quoteField(orderInfo.field), realOrder))
+	}
+	return strings.Join(orders, ","), nil
+}
+
+func legacyBuildInsert(table string, setMap []map[string]interface{}) (string, []interface{}, error) {
+	format := "INSERT INTO %s (%s) VALUES %s"
+	var vals []interface{}
+	if len(setMap) < 1 {
+		return "", nil, errInsertNullData
+	}
+	fields := resolveFields(setMap[0])
+	placeholder := "(" + strings.TrimRight(strings.Repeat("$%d,", len(fields)), ",") + ")"
+	var sets []string
+	for _, mapItem := range setMap {
+		sets = append(sets, placeholder)
+		for _, field := range fields {
+			val, ok := mapItem[strings.Trim(field, "`")]
+			if !ok {
+				return "", nil, errInsertDataNotMatch
+			}
+			vals = append(vals, val)
+		}
+	}
+	conds := fmt.Sprintf(format, quoteField(table), strings.Join(fields, ","), strings.Join(sets, ","))
+	var holders []interface{}
+	for i := 0; i < len(fields)*len(sets); i++ {
+		holders = append(holders, i+1)
+	}
+	return fmt.Sprintf(conds, holders...), vals, nil
+}
+
+func legacyBuildUpdate(table string, update map[string]interface{}, conditions ...Comparable) (string, []interface{}, error) {
+	var placeHolderIndex int
+	keys, vals := resolveKV(update)
+	var sets string
+	for _, k := range keys {
+		placeHolderIndex++
+		sets += fmt.Sprintf("%s=$%d,", quoteField(k), placeHolderIndex)
+	}
+	sets = strings.TrimRight(sets, ",")
+	cond := fmt.Sprintf("UPDATE %s SET %s", quoteField(table), sets)
+	whereString, whereVals := legacyWhereConnector(&placeHolderIndex, conditions...)
+	if "" != whereString {
+		cond = fmt.Sprintf("%s WHERE %s", cond, whereString)
+		vals = append(vals, whereVals...)
+	}
+	return cond, vals, nil
+}
+
+func legacyBuildDelete(table string, conditions ...Comparable) (string, []interface{}, error) {
+	var placeHolderIndex int
+	whereString, vals := legacyWhereConnector(&placeHolderIndex, conditions...)
+	if "" == whereString {
+		return fmt.Sprintf("DELETE FROM %s", table), nil, nil
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", quoteField(table), whereString), vals, nil
+}
+
+func legacyBuildSelect(table string, ufields []string, groupBy string, uOrderBy []eleOrderBy, limit *eleLimit, conditions ...Comparable) (string, []interface{}, error) {
+	var placeHolderIndex int
+	fields := "*"
+	if len(ufields) > 0 {
+		fields = strings.Join(ufields, ",")
+	}
+	cond := fmt.Sprintf("SELECT %s FROM %s", fields, quoteField(table))
+	where, having := splitCondition(conditions)
+	whereString, vals := legacyWhereConnector(&placeHolderIndex, where...)
+	if "" != whereString {
+		cond = fmt.Sprintf("%s WHERE %s", cond, whereString)
+	}
+	if "" != groupBy {
+		cond = fmt.Sprintf("%s GROUP BY %s", cond, quoteField(groupBy))
+	}
+	if nil != having {
+		havingString, havingVals := legacyWhereConnector(&placeHolderIndex, having...)
+		cond = fmt.Sprintf("%s HAVING %s", cond, havingString)
+		vals = append(vals, havingVals...)
+	}
+	if len(uOrderBy) != 0 {
+		str, err := legacyOrderBy(uOrderBy)
+		if nil != err {
+			return "", nil, err
+		}
+		cond = fmt.Sprintf("%s ORDER BY %s", cond, str)
+	}
+	if nil != limit {
+		cond = fmt.Sprintf("%s LIMIT %d OFFSET %d", cond, limit.begin, limit.step)
+	}
+	return cond, vals, nil
+}
+
+var renderConditions = []Comparable{
+	Eq{"foo": "bar", "qq": "tt"},
+	In{"age": {1, 3, 5, 7, 9}, "city": {"Beijing", "Chengdu"}},
+	Ne{"faith": "Muslim"},
+	Gt{"score": 60},
+	Gte{"level": 2},
+	Lt{"rank": 100},
+	Lte{"weight": 80},
+	Like{"name": "%deen%"},
+	nilComparable(0),
+	Gt{"total": 1000},
+}
+
+var renderOrderBy = []eleOrderBy{{"age", "desc"}, {"id", "Asc"}}
+
+var renderInsertData = []map[string]interface{}{
+	{"name": "deen", "age": 23, "city": "Beijing"},
+	{"name": "tony", "age": 30, "city": "Chengdu"},
+	{"name": "jack", "age": 35, "city": "Wuhan"},
+}
+
+func TestRenderMatchesLegacy(t *testing.T) {
+	ass := assert.New(t)
+
+	cond, vals, err := buildSelect("tb", []string{"a", "b"}, "department", renderOrderBy, &eleLimit{10, 20}, renderConditions...)
+	lcond, lvals, lerr := legacyBuildSelect("tb", []string{"a", "b"}, "department", renderOrderBy, &eleLimit{10, 20}, renderConditions...)
+	ass.Equal(lerr, err)
+	ass.Equal(lcond, cond)
+	ass.Equal(lvals, vals)
+
+	cond, vals, err = buildSelect("tb", nil, "", nil, nil)
+	lcond, lvals, lerr = legacyBuildSelect("tb", nil, "", nil, nil)
+	ass.Equal(lerr, err)
+	ass.Equal(lcond, cond)
+	ass.Equal(lvals, vals)
+
+	cond, vals, err = buildUpdate("tb", map[string]interface{}{"name": "deen", "age": 23}, renderConditions[:8]...)
+	lcond, lvals, lerr = legacyBuildUpdate("tb", map[string]interface{}{"name": "deen", "age": 23}, renderConditions[:8]...)
+	ass.Equal(lerr, err)
+	ass.Equal(lcond, cond)
+	ass.Equal(lvals, vals)
+
+	cond, vals, err = buildDelete("tb", renderConditions[:8]...)
+	lcond, lvals, lerr = legacyBuildDelete("tb", renderConditions[:8]...)
+	ass.Equal(lerr, err)
+	ass.Equal(lcond, cond)
+	ass.Equal(lvals, vals)
+
+	cond, vals, err = buildDelete("tb")
+	lcond, lvals, lerr = legacyBuildDelete("tb")
+	ass.Equal(lerr, err)
+	ass.Equal(lcond, cond)
+	ass.Equal(lvals, vals)
+
+	cond, vals, err = buildInsert("tb", renderInsertData)
+	lcond, lvals, lerr = legacyBuildInsert("tb", renderInsertData)
+	ass.Equal(lerr, err)
+	ass.Equal(lcond, cond)
+	ass.Equal(lvals, vals)
+
+	for _, c := range renderConditions {
+		var idx, lidx int
+		conds, vals := c.Build(&idx)
+		lconds, lvals := legacyConditionBuild(c, &lidx)
+		ass.Equal(lconds, conds)
+		ass.Equal(lvals, vals)
+		ass.Equal(lidx, idx)
+	}
+
+	_, err = orderBy([]eleOrderBy{{"age", "up"}})
+	_, lerr = legacyOrderBy([]eleOrderBy{{"age", "up"}})
+	ass.Equal(lerr, err)
+}
+
+func BenchmarkRenderSelect(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buildSelect("tb", []string{"a", "b"}, "department", renderOrderBy, &eleLimit{10, 20}, renderConditions...)
+	}
+}
+
+func BenchmarkRenderSelect_Legacy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyBuildSelect("tb", []string{"a", "b"}, "department", renderOrderBy, &eleLimit{10, 20}, renderConditions...)
+	}
+}
+
+func BenchmarkRenderUpdate(b *testing.B) {
+	b.ReportAllocs()
+	update := map[string]interface{}{"name": "deen", "age": 23}
+	for i := 0; i < b.N; i++ {
+		buildUpdate("tb", update, renderConditions[:8]...)
+	}
+}
+
+func BenchmarkRenderUpdate_Legacy(b *testing.B) {
+	b.ReportAllocs()
+	update := map[string]interface{}{"name": "deen", "age": 23}
+	for i := 0; i < b.N; i++ {
+		legacyBuildUpdate("tb", update, renderConditions[:8]...)
+	}
+}
+
+func BenchmarkRenderInsert(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buildInsert("tb", renderInsertData)
+	}
+}
+
+func BenchmarkRenderInsert_Legacy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyBuildInsert("tb", renderInsertData)
+	}
+}
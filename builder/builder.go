@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -55,12 +56,26 @@ type eleLimit struct {
 // the value of _limit must be a slice whose type should be []uint and must contain two uints(ie: []uint{0, 100}).
 // the value of _having must be a map just like where but only support =,in,>,>=,<,<=,<>,!=
 // for more examples,see README.md or open a issue.
-func BuildSelect(table string, where map[string]interface{}, selectField []string) (cond string, vals []interface{}, err error) {
+func BuildSelect(table string, where map[string]interface{}, selectField []string) (string, []interface{}, error) {
+	return selectFrom(table, where, selectField, 0)
+}
+
+// BuildSelectOrdered is the same as BuildSelect but its conditions are rendered in the given order
+func BuildSelectOrdered(table string, where OrderedWhere, selectField []string) (string, []interface{}, error) {
+	return selectFrom(table, where, selectField, 0)
+}
+
+// selectFrom is BuildSelect with the placeholders numbered after placeHolderIndex,
+// so values of the select list can take the leading ones
+func selectFrom(table string, where interface{}, selectField []string, placeHolderIndex int) (cond string, vals []interface{}, err error) {
 	var orderBy []eleOrderBy
 	var limit *eleLimit
 	var groupBy string
-	var having map[string]interface{}
-	copiedWhere := copyWhere(where)
+	var having interface{}
+	copiedWhere, rest, err := splitWhere(where)
+	if nil != err {
+		return
+	}
 	if val, ok := copiedWhere["_orderby"]; ok {
 		eleOrderBy, e := splitOrderBy(val.(string))
 		if e != nil {
@@ -105,7 +120,7 @@ func BuildSelect(table string, where map[string]interface{}, selectField []strin
 		}
 		delete(copiedWhere, "_limit")
 	}
-	conditions, release, err := getWhereConditions(copiedWhere)
+	conditions, release, err := getWhereConditions(rest)
 	if nil != err {
 		return
 	}
@@ -120,39 +135,44 @@ func BuildSelect(table string, where map[string]interface{}, selectField []strin
 		conditions = append(conditions, nilComparable(0))
 		conditions = append(conditions, havingCondition...)
 	}
-	return buildSelect(table, selectField, groupBy, orderBy, limit, conditions...)
-}
-
-func copyWhere(src map[string]interface{}) (target map[string]interface{}) {
-	target = make(map[string]interface{})
-	for k, v := range src {
-		target[k] = v
-	}
-	return
+	return buildSelectAt(placeHolderIndex, table, selectField, groupBy, orderBy, limit, conditions...)
 }
 
-func resolveHaving(having interface{}) (map[string]interface{}, error) {
-	var havingMap map[string]interface{}
-	var ok bool
-	if havingMap, ok = having.(map[string]interface{}); !ok {
+func resolveHaving(having interface{}) (interface{}, error) {
+	having = normalizeWhere(having)
+	switch having.(type) {
+	case map[string]interface{}, OrderedWhere:
+	default:
 		return nil, errHavingValueType
 	}
-	copiedMap := make(map[string]interface{})
-	for key, val := range havingMap {
+	err := rangeWhere(having, func(key string, val interface{}) error {
 		_, operator, err := splitKey(key)
 		if nil != err {
-			return nil, err
+			return err
 		}
 		if !isStringInSlice(operator, opOrder) {
-			return nil, errHavingUnsupportedOperator
+			return errHavingUnsupportedOperator
 		}
-		copiedMap[key] = val
+		return nil
+	})
+	if nil != err {
+		return nil, err
 	}
-	return copiedMap, nil
+	return having, nil
 }
 
 // BuildUpdate work as its name says
 func BuildUpdate(table string, where map[string]interface{}, update map[string]interface{}) (string, []interface{}, error) {
+	return updateWhere(table, where, update)
+}
+
+// BuildUpdateOrdered is the same as BuildUpdate but its conditions are rendered in the given order
+func BuildUpdateOrdered(table string, where OrderedWhere, update map[string]interface{}) (string, []interface{}, error) {
+	return updateWhere(table, where, update)
+}
+
+// updateWhere is BuildUpdate taking either kind of where
+func updateWhere(table string, where interface{}, update map[string]interface{}) (string, []interface{}, error) {
 	conditions, release, err := getWhereConditions(where)
 	if nil != err {
 		return "", nil, err
@@ -163,6 +183,16 @@ func BuildUpdate(table string, where map[string]interface{}, update map[string]i
 
 // BuildDelete work as its name says
 func BuildDelete(table string, where map[string]interface{}) (string, []interface{}, error) {
+	return deleteWhere(table, where)
+}
+
+// BuildDeleteOrdered is the same as BuildDelete but its conditions are rendered in the given order
+func BuildDeleteOrdered(table string, where OrderedWhere) (string, []interface{}, error) {
+	return deleteWhere(table, where)
+}
+
+// deleteWhere is BuildDelete taking either kind of where
+func deleteWhere(table string, where interface{}) (string, []interface{}, error) {
 	conditions, release, err := getWhereConditions(where)
 	if nil != err {
 		return "", nil, err
@@ -200,7 +230,26 @@ func isStringInSlice(str string, arr []string) bool {
 	return false
 }
 
-func getWhereConditions(where map[string]interface{}) ([]Comparable, func(), error) {
+func getWhereConditions(where interface{}) ([]Comparable, func(), error) {
+	switch w := normalizeWhere(where).(type) {
+	case nil:
+		return nil, emptyFunc, nil
+	case map[string]interface{}:
+		return getWhereMapConditions(w)
+	case OrderedWhere:
+		if len(w) == 0 {
+			return nil, emptyFunc, nil
+		}
+		o, err := newOrderedComparable(w)
+		if nil != err {
+			return nil, emptyFunc, err
+		}
+		return []Comparable{o}, emptyFunc, nil
+	}
+	return nil, emptyFunc, errWhereType
+}
+
+func getWhereMapConditions(where map[string]interface{}) ([]Comparable, func(), error) {
 	if len(where) == 0 {
 		return nil, emptyFunc, nil
 	}
@@ -383,7 +432,7 @@ func NamedQuery(sql string, data map[string]interface{}) (string, []interface{},
 		if v.Type().Kind() != reflect.Slice {
 			vals = append(vals, val)
 			placeHolderIndex++
-			return paramPlaceHolder + fmt.Sprintf("%d", placeHolderIndex)
+			return paramPlaceHolder + strconv.Itoa(placeHolderIndex)
 		}
 		length := v.Len()
 		for i := 0; i < length; i++ {
@@ -401,13 +450,8 @@ func createMultiPlaceholders(num int, placeHolderIndex *int) string {
 	if 0 == num {
 		return ""
 	}
-	placeHolder := "("
-	for i := 0; i < num; i++ {
-		*placeHolderIndex++
-		placeHolder += fmt.Sprintf("$%d", *placeHolderIndex)
-		if i != num-1 {
-			placeHolder += ","
-		}
-	}
-	return placeHolder + ")"
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.writePlaceholders(num, placeHolderIndex)
+	return buf.String()
 }
@@ -0,0 +1,391 @@
+package builder
+
+import (
+	"container/list"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultCacheSize = 1024
+
+// errUncacheable means the statement contains values which can't be traced back to the where map
+var errUncacheable = errors.New("[builder] statement can't be cached")
+
+const (
+	srcWhere = iota
+	srcHaving
+	srcUpdate
+)
+
+// valueRef records where a value of a cached statement comes from
+type valueRef struct {
+	src int
+	key string
+	// position of the key in an OrderedWhere
+	pos int
+	// index in the slice of an "in" condition, -1 means the value itself
+	index int
+}
+
+// valueMarker stands in for a real value while rendering a statement for the cache,
+// so that the rendered vals tell the order in which the values are bound
+type valueMarker int
+
+type cacheEntry struct {
+	key  string
+	cond string
+	refs []valueRef
+}
+
+// CacheStats is a snapshot of the statistics of a Cache
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// Cache caches the statements rendered by BuildSelect,BuildUpdate and BuildDelete.
+// statements are keyed by the table, the keys of the where map(with their operators),
+// the length of every "in" slice and the values of _orderby,_groupby,_limit(an OrderedWhere is keyed by its order too),
+// so a hit only collects the values from the where map instead of rendering the statement again.
+// the least recently used statement is evicted when the cache is full.
+// it's safe for concurrent use
+type Cache struct {
+	mu        sync.Mutex
+	size      int
+	ll        *list.List
+	entries   map[string]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// NewCache returns a Cache holding at most size statements, a default size is used if size <= 0
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	return &Cache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Stats returns the statistics of the cache
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.ll.Len(),
+	}
+}
+
+func (c *Cache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ele, ok := c.entries[key]; ok {
+		c.hits++
+		c.ll.MoveToFront(ele)
+		return ele.Value.(*cacheEntry)
+	}
+	c.misses++
+	return nil
+}
+
+func (c *Cache) add(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ele, ok := c.entries[entry.key]; ok {
+		c.ll.MoveToFront(ele)
+		ele.Value = entry
+		return
+	}
+	c.entries[entry.key] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.evictions++
+	}
+}
+
+// BuildSelect is the same as the package level BuildSelect but uses the cache
+func (c *Cache) BuildSelect(table string, where map[string]interface{}, selectField []string) (string, []interface{}, error) {
+	return c.buildSelect(table, where, selectField)
+}
+
+// BuildSelectOrdered is the same as the package level BuildSelectOrdered but uses the cache
+func (c *Cache) BuildSelectOrdered(table string, where OrderedWhere, selectField []string) (string, []interface{}, error) {
+	return c.buildSelect(table, where, selectField)
+}
+
+// BuildUpdate is the same as the package level BuildUpdate but uses the cache
+func (c *Cache) BuildUpdate(table string, where map[string]interface{}, update map[string]interface{}) (string, []interface{}, error) {
+	return c.buildUpdate(table, where, update)
+}
+
+// BuildUpdateOrdered is the same as the package level BuildUpdateOrdered but uses the cache
+func (c *Cache) BuildUpdateOrdered(table string, where OrderedWhere, update map[string]interface{}) (string, []interface{}, error) {
+	return c.buildUpdate(table, where, update)
+}
+
+// BuildDelete is the same as the package level BuildDelete but uses the cache
+func (c *Cache) BuildDelete(table string, where map[string]interface{}) (string, []interface{}, error) {
+	return c.buildDelete(table, where)
+}
+
+// BuildDeleteOrdered is the same as the package level BuildDeleteOrdered but uses the cache
+func (c *Cache) BuildDeleteOrdered(table string, where OrderedWhere) (string, []interface{}, error) {
+	return c.buildDelete(table, where)
+}
+
+func (c *Cache) buildSelect(table string, where interface{}, selectField []string) (string, []interface{}, error) {
+	key, ok := cacheKey("select", table, selectField, where, nil)
+	if !ok {
+		return selectFrom(table, where, selectField, 0)
+	}
+	return c.build(key, where, nil, func(where interface{}, _ map[string]interface{}) (string, []interface{}, error) {
+		return selectFrom(table, where, selectField, 0)
+	})
+}
+
+func (c *Cache) buildUpdate(table string, where interface{}, update map[string]interface{}) (string, []interface{}, error) {
+	key, ok := cacheKey("update", table, nil, where, update)
+	if !ok {
+		return updateWhere(table, where, update)
+	}
+	return c.build(key, where, update, func(where interface{}, update map[string]interface{}) (string, []interface{}, error) {
+		return updateWhere(table, where, update)
+	})
+}
+
+func (c *Cache) buildDelete(table string, where interface{}) (string, []interface{}, error) {
+	key, ok := cacheKey("delete", table, nil, where, nil)
+	if !ok {
+		return deleteWhere(table, where)
+	}
+	return c.build(key, where, nil, func(where interface{}, _ map[string]interface{}) (string, []interface{}, error) {
+		return deleteWhere(table, where)
+	})
+}
+
+type buildFunc func(where interface{}, update map[string]interface{}) (string, []interface{}, error)
+
+func (c *Cache) build(key string, where interface{}, update map[string]interface{}, f buildFunc) (string, []interface{}, error) {
+	entry := c.get(key)
+	if nil == entry {
+		var err error
+		entry, err = render(key, where, update, f)
+		if errUncacheable == err {
+			return f(where, update)
+		}
+		if nil != err {
+			return "", nil, err
+		}
+		c.add(entry)
+	}
+	return entry.cond, collectValues(entry.refs, where, update), nil
+}
+
+// render builds the statement with every value replaced by a valueMarker,
+// and resolves the markers in the rendered vals into valueRefs
+func render(key string, where interface{}, update map[string]interface{}, f buildFunc) (*cacheEntry, error) {
+	var refs []valueRef
+	markedWhere := markWhere(where, srcWhere, &refs)
+	var markedUpdate map[string]interface{}
+	if nil != update {
+		markedUpdate = make(map[string]interface{}, len(update))
+		for k := range update {
+			refs = append(refs, valueRef{srcUpdate, k, -1, -1})
+			markedUpdate[k] = valueMarker(len(refs) - 1)
+		}
+	}
+	cond, vals, err := f(markedWhere, markedUpdate)
+	if nil != err {
+		return nil, err
+	}
+	ordered := make([]valueRef, len(vals))
+	for i, v := range vals {
+		m, ok := v.(valueMarker)
+		if !ok {
+			return nil, errUncacheable
+		}
+		ordered[i] = refs[m]
+	}
+	return &cacheEntry{
+		key:  key,
+		cond: cond,
+		refs: ordered,
+	}, nil
+}
+
+// markWhere returns a copy of where whose values are replaced by valueMarkers
+func markWhere(where interface{}, src int, refs *[]valueRef) interface{} {
+	mark := func(key string, pos int, val interface{}) interface{} {
+		switch {
+		case src == srcWhere && (key == "_orderby" || key == "_groupby" || key == "_limit"):
+			return val
+		case src == srcWhere && key == "_having":
+			return markWhere(val, srcHaving, refs)
+		}
+		_, operator, _ := splitKey(key)
+		if operator != opIn {
+			*refs = append(*refs, valueRef{src, key, pos, -1})
+			return valueMarker(len(*refs) - 1)
+		}
+		markers := make([]interface{}, reflect.ValueOf(val).Len())
+		for i := range markers {
+			*refs = append(*refs, valueRef{src, key, pos, i})
+			markers[i] = valueMarker(len(*refs) - 1)
+		}
+		return markers
+	}
+	switch w := where.(type) {
+	case map[string]interface{}:
+		marked := make(map[string]interface{}, len(w))
+		for k, v := range w {
+			marked[k] = mark(k, -1, v)
+		}
+		return marked
+	case OrderedWhere:
+		marked := make(OrderedWhere, len(w))
+		for i, kv := range w {
+			marked[i] = KV{kv.Key, mark(kv.Key, i, kv.Value)}
+		}
+		return marked
+	}
+	return where
+}
+
+func lookupWhere(where interface{}, key string, pos int) interface{} {
+	switch w := where.(type) {
+	case map[string]interface{}:
+		return w[key]
+	case OrderedWhere:
+		if pos >= 0 {
+			return w[pos].Value
+		}
+		for _, kv := range w {
+			if kv.Key == key {
+				return kv.Value
+			}
+		}
+	}
+	return nil
+}
+
+func collectValues(refs []valueRef, where interface{}, update map[string]interface{}) []interface{} {
+	if len(refs) == 0 {
+		return nil
+	}
+	vals := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		var val interface{}
+		switch ref.src {
+		case srcWhere:
+			val = lookupWhere(where, ref.key, ref.pos)
+		case srcHaving:
+			val = lookupWhere(lookupWhere(where, "_having", -1), ref.key, ref.pos)
+		case srcUpdate:
+			val = update[ref.key]
+		}
+		if ref.index >= 0 {
+			val = reflect.ValueOf(val).Index(ref.index).Interface()
+		}
+		vals[i] = val
+	}
+	return vals
+}
+
+// cacheKey describes the shape of the statement, false is returned if the where map
+// can't be cached(it's malformed), and the caller should fall back to the plain builder
+func cacheKey(kind, table string, selectField []string, where interface{}, update map[string]interface{}) (string, bool) {
+	var sb strings.Builder
+	sb.WriteString(kind)
+	sb.WriteByte(0)
+	sb.WriteString(table)
+	sb.WriteByte(0)
+	sb.WriteString(strings.Join(selectField, "\x01"))
+	sb.WriteByte(0)
+	if !writeShape(&sb, where, true) {
+		return "", false
+	}
+	sb.WriteByte(0)
+	for _, k := range sortedMapKeys(update) {
+		sb.WriteString(k)
+		sb.WriteByte(1)
+	}
+	return sb.String(), true
+}
+
+func writeShape(sb *strings.Builder, where interface{}, top bool) bool {
+	if _, ok := where.(OrderedWhere); ok {
+		sb.WriteByte('o')
+	}
+	err := rangeWhere(where, func(k string, v interface{}) error {
+		sb.WriteString(k)
+		switch {
+		case top && (k == "_orderby" || k == "_groupby"):
+			s, ok := v.(string)
+			if !ok {
+				return errUncacheable
+			}
+			sb.WriteByte('=')
+			sb.WriteString(s)
+		case top && k == "_limit":
+			arr, ok := v.([]uint)
+			if !ok {
+				return errUncacheable
+			}
+			for _, u := range arr {
+				sb.WriteByte('=')
+				sb.WriteString(strconv.FormatUint(uint64(u), 10))
+			}
+		case top && k == "_having":
+			switch v.(type) {
+			case map[string]interface{}, OrderedWhere:
+			default:
+				return errUncacheable
+			}
+			sb.WriteByte('{')
+			if !writeShape(sb, v, false) {
+				return errUncacheable
+			}
+			sb.WriteByte('}')
+		default:
+			_, operator, err := splitKey(k)
+			if nil != err {
+				return err
+			}
+			if operator == opIn {
+				rv := reflect.ValueOf(v)
+				if rv.Kind() != reflect.Slice {
+					return errUncacheable
+				}
+				sb.WriteByte('#')
+				sb.WriteString(strconv.Itoa(rv.Len()))
+			}
+		}
+		sb.WriteByte(1)
+		return nil
+	})
+	return nil == err
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,150 @@
+package builder
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheBuildSelect(t *testing.T) {
+	cache := NewCache(10)
+	ass := assert.New(t)
+	var data = []map[string]interface{}{
+		{
+			"name":     "deen",
+			"age in":   []int{1, 2, 3},
+			"score >":  60,
+			"_groupby": "name",
+			"_having":  map[string]interface{}{"total >": 10, "total in": []interface{}{1, 2}},
+			"_orderby": "age desc",
+			"_limit":   []uint{10, 0},
+		},
+		{
+			"name":     "tony",
+			"age in":   []int{4, 5, 6},
+			"score >":  70,
+			"_groupby": "name",
+			"_having":  map[string]interface{}{"total >": 20, "total in": []interface{}{3, 4}},
+			"_orderby": "age desc",
+			"_limit":   []uint{10, 0},
+		},
+		{
+			"name":     "jack",
+			"age in":   []int{7},
+			"score >":  80,
+			"_groupby": "name",
+			"_having":  map[string]interface{}{"total >": 30, "total in": []interface{}{5, 6}},
+			"_orderby": "age desc",
+			"_limit":   []uint{10, 0},
+		},
+		{
+			"name":     "jack",
+			"age in":   []int{7},
+			"score >":  80,
+			"_groupby": "name",
+			"_having":  map[string]interface{}{"total >": 30, "total in": []interface{}{5, 6}},
+			"_orderby": "age desc",
+			"_limit":   []uint{20, 10},
+		},
+	}
+	for _, where := range data {
+		cond, vals, err := cache.BuildSelect("tb", where, []string{"name", "count(*) as total"})
+		expectCond, expectVals, expectErr := BuildSelect("tb", where, []string{"name", "count(*) as total"})
+		ass.Equal(expectErr, err)
+		ass.Equal(expectCond, cond)
+		ass.Equal(expectVals, vals)
+	}
+	ass.Equal(CacheStats{Hits: 1, Misses: 3, Size: 3}, cache.Stats())
+}
+
+func TestCacheBuildUpdateDelete(t *testing.T) {
+	cache := NewCache(10)
+	ass := assert.New(t)
+	for i := 0; i < 3; i++ {
+		where := map[string]interface{}{"id in": []interface{}{i, i + 1}, "name": "deen"}
+		update := map[string]interface{}{"age": i, "role": "driver"}
+		cond, vals, err := cache.BuildUpdate("tb", where, update)
+		ass.NoError(err)
+		ass.Equal("UPDATE tb SET age=$1,role=$2 WHERE (name=$3 AND id IN ($4,$5))", cond)
+		ass.Equal([]interface{}{i, "driver", "deen", i, i + 1}, vals)
+
+		cond, vals, err = cache.BuildDelete("tb", where)
+		ass.NoError(err)
+		ass.Equal("DELETE FROM tb WHERE (name=$1 AND id IN ($2,$3))", cond)
+		ass.Equal([]interface{}{"deen", i, i + 1}, vals)
+	}
+	cond, vals, err := cache.BuildDelete("tb", nil)
+	ass.NoError(err)
+	ass.Equal("DELETE FROM tb", cond)
+	ass.Nil(vals)
+	ass.Equal(CacheStats{Hits: 4, Misses: 3, Size: 3}, cache.Stats())
+}
+
+func TestCacheErrors(t *testing.T) {
+	cache := NewCache(10)
+	ass := assert.New(t)
+	_, _, err := cache.BuildSelect("tb", map[string]interface{}{"age in": 1}, nil)
+	ass.Equal(errWhereInType, err)
+	_, _, err = cache.BuildSelect("tb", map[string]interface{}{"age in": []int{}}, nil)
+	ass.Equal(errEmptyINCondition, err)
+	_, _, err = cache.BuildSelect("tb", map[string]interface{}{"age ~": 1}, nil)
+	ass.Equal(ErrUnsupportedOperator, err)
+	_, _, err = cache.BuildSelect("tb", map[string]interface{}{"_limit": 1}, nil)
+	ass.Equal(errLimitValueType, err)
+	ass.Equal(0, cache.Stats().Size)
+	cond, vals, err := cache.BuildUpdate("tb", map[string]interface{}{"_orderby": "age desc"}, map[string]interface{}{"age": 1})
+	ass.NoError(err)
+	ass.Equal("UPDATE tb SET age=$1 WHERE (_orderby=$2)", cond)
+	ass.Equal([]interface{}{1, "age desc"}, vals)
+}
+
+func TestCacheEviction(t *testing.T) {
+	cache := NewCache(2)
+	ass := assert.New(t)
+	for _, key := range []string{"a", "b", "a", "c", "b"} {
+		_, _, err := cache.BuildSelect("tb", map[string]interface{}{key: 1}, nil)
+		ass.NoError(err)
+	}
+	ass.Equal(CacheStats{Hits: 1, Misses: 4, Evictions: 2, Size: 2}, cache.Stats())
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	cache := NewCache(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				where := map[string]interface{}{"name": i, "age in": make([]int, j%6+1)}
+				cond, vals, err := cache.BuildSelect("tb", where, nil)
+				expectCond, expectVals, _ := BuildSelect("tb", where, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, expectCond, cond)
+				assert.Equal(t, expectVals, vals)
+			}
+		}(i)
+	}
+	wg.Wait()
+	ass := assert.New(t)
+	stats := cache.Stats()
+	ass.Equal(uint64(800), stats.Hits+stats.Misses)
+	ass.True(stats.Size <= 4)
+}
+
+func BenchmarkCacheBuildSelect(b *testing.B) {
+	cache := NewCache(0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache.BuildSelect("tb", map[string]interface{}{
+			"foo":      "bar",
+			"qq":       "tt",
+			"age in":   []interface{}{1, 3, 5, 7, 9},
+			"faith <>": "Muslim",
+			"_orderby": "age desc",
+			"_groupby": "department",
+			"_limit":   []uint{0, 100},
+		}, []string{"a", "b", "c"})
+	}
+}
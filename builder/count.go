@@ -0,0 +1,88 @@
+package builder
+
+// TotalCountField is the column BuildSelectWithTotal puts the total number of rows in
+const TotalCountField = "total_count"
+
+// withoutKeys returns a copy of where without the given keys
+func withoutKeys(where interface{}, keys ...string) (interface{}, error) {
+	switch w := normalizeWhere(where).(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(w))
+		for k, v := range w {
+			if !isStringInSlice(k, keys) {
+				copied[k] = v
+			}
+		}
+		return copied, nil
+	case OrderedWhere:
+		copied := make(OrderedWhere, 0, len(w))
+		for _, kv := range w {
+			if !isStringInSlice(kv.Key, keys) {
+				copied = append(copied, kv)
+			}
+		}
+		return copied, nil
+	}
+	return nil, errWhereType
+}
+
+// BuildCount builds a query counting the rows matched by the where map of a BuildSelect,
+// _orderby and _limit are dropped so the same where map of a page query can be passed directly.
+// if there's a _groupby, the grouped query is wrapped in a subquery so the number of groups is counted
+func BuildCount(table string, where map[string]interface{}) (string, []interface{}, error) {
+	return countWhere(table, where)
+}
+
+// BuildCountOrdered is the same as BuildCount but its conditions are rendered in the given order
+func BuildCountOrdered(table string, where OrderedWhere) (string, []interface{}, error) {
+	return countWhere(table, where)
+}
+
+// countWhere is BuildCount taking either kind of where
+func countWhere(table string, where interface{}) (string, []interface{}, error) {
+	stripped, err := withoutKeys(where, "_orderby", "_limit")
+	if nil != err {
+		return "", nil, err
+	}
+	special, _, err := splitWhere(stripped)
+	if nil != err {
+		return "", nil, err
+	}
+	groupBy, ok := special["_groupby"]
+	if !ok {
+		return selectFrom(table, stripped, []string{"count(*)"}, 0)
+	}
+	s, ok := groupBy.(string)
+	if !ok {
+		return "", nil, errGroupByValueType
+	}
+	cond, vals, err := selectFrom(table, stripped, []string{s}, 0)
+	if nil != err {
+		return "", nil, err
+	}
+	return "SELECT count(*) FROM (" + cond + ") AS t", vals, nil
+}
+
+// BuildSelectWithTotal is the same as BuildSelect but adds count(*) OVER() AS total_count to the selected fields,
+// so the total number of rows(ignoring _limit) comes back with every row of the page in a single round trip
+func BuildSelectWithTotal(table string, where map[string]interface{}, selectField []string) (string, []interface{}, error) {
+	return selectWithTotal(table, where, selectField)
+}
+
+// BuildSelectWithTotalOrdered is the same as BuildSelectWithTotal but its conditions are rendered in the given order
+func BuildSelectWithTotalOrdered(table string, where OrderedWhere, selectField []string) (string, []interface{}, error) {
+	return selectWithTotal(table, where, selectField)
+}
+
+// selectWithTotal is BuildSelectWithTotal taking either kind of where
+func selectWithTotal(table string, where interface{}, selectField []string) (string, []interface{}, error) {
+	fields := make([]string, 0, len(selectField)+2)
+	if len(selectField) == 0 {
+		fields = append(fields, "*")
+	}
+	fields = append(fields, selectField...)
+	fields = append(fields, "count(*) OVER() AS "+TotalCountField)
+	return selectFrom(table, where, fields, 0)
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCount(t *testing.T) {
+	var data = []struct {
+		where interface{}
+		cond  string
+		vals  []interface{}
+		err   error
+	}{
+		{
+			where: map[string]interface{}{
+				"age >":    10,
+				"city in":  []string{"Beijing", "Chengdu"},
+				"_orderby": "age desc",
+				"_limit":   []uint{10, 20},
+			},
+			cond: "SELECT count(*) FROM tb WHERE (city IN ($1,$2) AND age>$3)",
+			vals: []interface{}{"Beijing", "Chengdu", 10},
+		},
+		{
+			where: map[string]interface{}{
+				"age >":    10,
+				"_groupby": "city",
+				"_having":  map[string]interface{}{"count(*) >": 5},
+				"_orderby": "city asc",
+				"_limit":   []uint{10, 0},
+			},
+			cond: "SELECT count(*) FROM (SELECT city FROM tb WHERE (age>$1) GROUP BY city HAVING (count(*)>$2)) AS t",
+			vals: []interface{}{10, 5},
+		},
+		{
+			where: OrderedWhere{{"name", "deen"}, {"age >", 10}, {"_limit", []uint{10, 0}}},
+			cond:  "SELECT count(*) FROM tb WHERE (name=$1 AND age>$2)",
+			vals:  []interface{}{"deen", 10},
+		},
+		{
+			where: nil,
+			cond:  "SELECT count(*) FROM tb",
+		},
+		{
+			where: map[string]interface{}{"_groupby": 1},
+			err:   errGroupByValueType,
+		},
+		{
+			where: 1,
+			err:   errWhereType,
+		},
+	}
+	ass := assert.New(t)
+	for idx, tc := range data {
+		cond, vals, err := countWhere("tb", tc.where)
+		ass.Equal(tc.err, err, "idx:%d", idx)
+		ass.Equal(tc.cond, cond, "idx:%d", idx)
+		ass.Equal(tc.vals, vals, "idx:%d", idx)
+	}
+}
+
+func TestBuildSelectWithTotal(t *testing.T) {
+	ass := assert.New(t)
+	where := map[string]interface{}{"age >": 10, "_limit": []uint{10, 0}}
+	cond, vals, err := BuildSelectWithTotal("tb", where, []string{"id", "name"})
+	ass.NoError(err)
+	ass.Equal("SELECT id,name,count(*) OVER() AS total_count FROM tb WHERE (age>$1) LIMIT 10 OFFSET 0", cond)
+	ass.Equal([]interface{}{10}, vals)
+
+	cond, _, err = BuildSelectWithTotal("tb", where, nil)
+	ass.NoError(err)
+	ass.Equal("SELECT *,count(*) OVER() AS total_count FROM tb WHERE (age>$1) LIMIT 10 OFFSET 0", cond)
+
+	cond, vals, err = BuildSelectWithTotalOrdered("tb", OrderedWhere{{"name", "deen"}, {"age >", 10}}, []string{"id"})
+	ass.NoError(err)
+	ass.Equal("SELECT id,count(*) OVER() AS total_count FROM tb WHERE (name=$1 AND age>$2)", cond)
+	ass.Equal([]interface{}{"deen", 10}, vals)
+}
+
+func TestBuildCountOrdered(t *testing.T) {
+	ass := assert.New(t)
+	cond, vals, err := BuildCount("tb", map[string]interface{}{"age >": 10, "_limit": []uint{10, 0}})
+	ass.NoError(err)
+	ass.Equal("SELECT count(*) FROM tb WHERE (age>$1)", cond)
+	ass.Equal([]interface{}{10}, vals)
+
+	cond, vals, err = BuildCountOrdered("tb", OrderedWhere{{"name", "deen"}, {"age >", 10}, {"_orderby", "age desc"}})
+	ass.NoError(err)
+	ass.Equal("SELECT count(*) FROM tb WHERE (name=$1 AND age>$2)", cond)
+	ass.Equal([]interface{}{"deen", 10}, vals)
+}
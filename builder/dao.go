@@ -2,8 +2,8 @@ package builder
 
 import (
 	"errors"
-	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -22,12 +22,22 @@ type Comparable interface {
 	Build(placeHolderIndex *int) ([]string, []interface{})
 }
 
+// conditionAppender is implemented by the built-in Comparables,
+// it renders the conditions into buf directly which saves building a []string
+type conditionAppender interface {
+	appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{}
+}
+
 type nilComparable byte
 
 func (n nilComparable) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return nil, nil
 }
 
+func (n nilComparable) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	return vals
+}
+
 // Like means like
 type Like map[string]interface{}
 
@@ -45,12 +55,23 @@ func (l Like) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	for j := 0; j < len(cond); j++ {
 		val := l[cond[j]]
 		*placeHolderIndex++
-		cond[j] = cond[j] + " LIKE $" + fmt.Sprintf("%d", *placeHolderIndex)
+		cond[j] = cond[j] + " LIKE $" + strconv.Itoa(*placeHolderIndex)
 		vals = append(vals, val)
 	}
 	return cond, vals
 }
 
+func (l Like) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	for _, k := range buf.sortedKeys(l) {
+		buf.and()
+		buf.writeString(k)
+		buf.writeString(" LIKE ")
+		buf.writePlaceholder(placeHolderIndex)
+		vals = append(vals, l[k])
+	}
+	return vals
+}
+
 //Eq means equal(=)
 type Eq map[string]interface{}
 
@@ -59,6 +80,10 @@ func (e Eq) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return build(e, "=", placeHolderIndex)
 }
 
+func (e Eq) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	return appendCompare(buf, e, "=", placeHolderIndex, vals)
+}
+
 //Ne means Not Equal(!=)
 type Ne map[string]interface{}
 
@@ -67,6 +92,10 @@ func (n Ne) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return build(n, "!=", placeHolderIndex)
 }
 
+func (n Ne) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	return appendCompare(buf, n, "!=", placeHolderIndex, vals)
+}
+
 //Lt means less than(<)
 type Lt map[string]interface{}
 
@@ -75,6 +104,10 @@ func (l Lt) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return build(l, "<", placeHolderIndex)
 }
 
+func (l Lt) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	return appendCompare(buf, l, "<", placeHolderIndex, vals)
+}
+
 //Lte means less than or equal(<=)
 type Lte map[string]interface{}
 
@@ -83,6 +116,10 @@ func (l Lte) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return build(l, "<=", placeHolderIndex)
 }
 
+func (l Lte) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	return appendCompare(buf, l, "<=", placeHolderIndex, vals)
+}
+
 //Gt means greater than(>)
 type Gt map[string]interface{}
 
@@ -91,6 +128,10 @@ func (g Gt) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return build(g, ">", placeHolderIndex)
 }
 
+func (g Gt) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	return appendCompare(buf, g, ">", placeHolderIndex, vals)
+}
+
 //Gte means greater than or equal(>=)
 type Gte map[string]interface{}
 
@@ -99,6 +140,10 @@ func (g Gte) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return build(g, ">=", placeHolderIndex)
 }
 
+func (g Gte) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	return appendCompare(buf, g, ">=", placeHolderIndex, vals)
+}
+
 //In means in
 type In map[string][]interface{}
 
@@ -121,16 +166,26 @@ func (i In) Build(placeHolderIndex *int) ([]string, []interface{}) {
 	return cond, vals
 }
 
-func buildIn(field string, vals []interface{}, placeHolderIndex *int) (cond string) {
-	for i := 0; i < len(vals); i++ {
-		*placeHolderIndex++
-		cond += fmt.Sprintf("$%d", *placeHolderIndex)
-		if i != len(vals)-1 {
-			cond += ","
-		}
+func (i In) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	for _, k := range buf.sortedSliceKeys(i) {
+		buf.and()
+		appendIn(buf, k, len(i[k]), placeHolderIndex)
+		vals = append(vals, i[k]...)
 	}
-	cond = fmt.Sprintf("%s IN (%s)", quoteField(field), cond)
-	return
+	return vals
+}
+
+func buildIn(field string, vals []interface{}, placeHolderIndex *int) string {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	appendIn(buf, field, len(vals), placeHolderIndex)
+	return buf.String()
+}
+
+func appendIn(buf *sqlBuffer, field string, num int, placeHolderIndex *int) {
+	buf.writeString(quoteField(field))
+	buf.writeString(" IN ")
+	buf.writePlaceholders(num, placeHolderIndex)
 }
 
 func build(m map[string]interface{}, op string, placeHolderIndex *int) ([]string, []interface{}) {
@@ -154,22 +209,49 @@ func build(m map[string]interface{}, op string, placeHolderIndex *int) ([]string
 	return cond, vals
 }
 
+func appendCompare(buf *sqlBuffer, m map[string]interface{}, op string, placeHolderIndex *int, vals []interface{}) []interface{} {
+	for _, k := range buf.sortedKeys(m) {
+		buf.and()
+		buf.writeString(quoteField(k))
+		buf.writeString(op)
+		buf.writePlaceholder(placeHolderIndex)
+		vals = append(vals, m[k])
+	}
+	return vals
+}
+
 func assembleExpression(field, op string, placeHolderIndex *int) string {
-	return quoteField(field) + op + "$" + fmt.Sprintf("%d", *placeHolderIndex)
+	return quoteField(field) + op + "$" + strconv.Itoa(*placeHolderIndex)
 }
 
 func orderBy(orderMap []eleOrderBy) (string, error) {
-	var orders []string
-	for _, orderInfo := range orderMap {
-		realOrder := strings.ToUpper(orderInfo.order)
-		if realOrder != "ASC" && realOrder != "DESC" {
-			return "", errOrderByParam
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := appendOrderBy(buf, orderMap); nil != err {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func appendOrderBy(buf *sqlBuffer, orderMap []eleOrderBy) error {
+	for i, orderInfo := range orderMap {
+		var realOrder string
+		switch {
+		case strings.EqualFold(orderInfo.order, "ASC"):
+			realOrder = "ASC"
+		case strings.EqualFold(orderInfo.order, "DESC"):
+			realOrder = "DESC"
+		default:
+			return errOrderByParam
+		}
+		if i > 0 {
+			buf.writeByte(',')
 		}
-		order := fmt.Sprintf("%s %s", quoteField(orderInfo.field), realOrder)
-		orders = append(orders, order)
+		buf.writeString(quoteField(orderInfo.field))
+		buf.writeByte(' ')
+		buf.writeString(realOrder)
 	}
-	orderby := strings.Join(orders, ",")
-	return orderby, nil
+	return nil
 }
 
 func resolveKV(m map[string]interface{}) (keys []string, vals []interface{}) {
@@ -196,21 +278,46 @@ func whereConnector(placeHolderIndex *int, conditions ...Comparable) (string, []
 	if len(conditions) == 0 {
 		return "", nil
 	}
-	var where []string
-	var values []interface{}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	values := appendWhere(buf, "", placeHolderIndex, nil, conditions...)
+	if 0 == len(buf.b) {
+		return "", nil
+	}
+	return buf.String(), values
+}
+
+// appendWhere writes prefix(cond1 AND cond2 ...) into buf and appends the values to vals,
+// nothing is written if there's no condition at all
+func appendWhere(buf *sqlBuffer, prefix string, placeHolderIndex *int, vals []interface{}, conditions ...Comparable) []interface{} {
+	if len(conditions) == 0 {
+		return vals
+	}
+	mark := len(buf.b)
+	buf.writeString(prefix)
+	buf.writeByte('(')
+	buf.n = 0
 	for _, cond := range conditions {
-		cons, vals := cond.Build(placeHolderIndex)
+		if appender, ok := cond.(conditionAppender); ok {
+			vals = appender.appendTo(buf, placeHolderIndex, vals)
+			continue
+		}
+		cons, vs := cond.Build(placeHolderIndex)
 		if nil == cons {
 			continue
 		}
-		where = append(where, cons...)
-		values = append(values, vals...)
+		for _, c := range cons {
+			buf.and()
+			buf.writeString(c)
+		}
+		vals = append(vals, vs...)
 	}
-	if 0 == len(where) {
-		return "", nil
+	if 0 == buf.n {
+		buf.b = buf.b[:mark]
+		return vals
 	}
-	whereString := "(" + strings.Join(where, " AND ") + ")"
-	return whereString, values
+	buf.writeByte(')')
+	return vals
 }
 
 // deprecated
@@ -219,63 +326,80 @@ func quoteField(field string) string {
 }
 
 func buildInsert(table string, setMap []map[string]interface{}) (string, []interface{}, error) {
-	format := "INSERT INTO %s (%s) VALUES %s"
-	var fields []string
-	var vals []interface{}
 	if len(setMap) < 1 {
 		return "", nil, errInsertNullData
 	}
-	fields = resolveFields(setMap[0])
-	placeholder := "(" + strings.TrimRight(strings.Repeat("$%d,", len(fields)), ",") + ")"
-	var sets []string
-	for _, mapItem := range setMap {
-		sets = append(sets, placeholder)
-		for _, field := range fields {
+	fields := resolveFields(setMap[0])
+	vals := make([]interface{}, 0, len(fields)*len(setMap))
+	buf := getBuffer()
+	defer putBuffer(buf)
+	var placeHolderIndex int
+	buf.writeString("INSERT INTO ")
+	buf.writeString(quoteField(table))
+	buf.writeString(" (")
+	for i, field := range fields {
+		if i > 0 {
+			buf.writeByte(',')
+		}
+		buf.writeString(field)
+	}
+	buf.writeString(") VALUES ")
+	for i, mapItem := range setMap {
+		if i > 0 {
+			buf.writeByte(',')
+		}
+		buf.writeByte('(')
+		for j, field := range fields {
 			val, ok := mapItem[strings.Trim(field, "`")]
 			if !ok {
 				return "", nil, errInsertDataNotMatch
 			}
+			if j > 0 {
+				buf.writeByte(',')
+			}
+			if _, ok := val.(sqlDefault); ok {
+				buf.writeString("DEFAULT")
+				continue
+			}
 			vals = append(vals, val)
+			buf.writePlaceholder(&placeHolderIndex)
 		}
+		buf.writeByte(')')
 	}
-	conds := fmt.Sprintf(format, quoteField(table), strings.Join(fields, ","), strings.Join(sets, ","))
-	var holders []interface{}
-	for i := 0; i < len(fields)*len(sets); i++ {
-		holders = append(holders, i+1)
-	}
-	conds = fmt.Sprintf(conds, holders...)
-	return conds, vals, nil
+	return buf.String(), vals, nil
 }
 
+// sqlDefault is rendered as DEFAULT by buildInsert instead of a placeholder
+type sqlDefault struct{}
+
 func buildUpdate(table string, update map[string]interface{}, conditions ...Comparable) (string, []interface{}, error) {
 	var placeHolderIndex int
-	format := "UPDATE %s SET %s"
 	keys, vals := resolveKV(update)
-	var sets string
-	for _, k := range keys {
-		placeHolderIndex++
-		sets += fmt.Sprintf("%s=$%d,", quoteField(k), placeHolderIndex)
-	}
-	sets = strings.TrimRight(sets, ",")
-	cond := fmt.Sprintf(format, quoteField(table), sets)
-	whereString, whereVals := whereConnector(&placeHolderIndex, conditions...)
-	if "" != whereString {
-		cond = fmt.Sprintf("%s WHERE %s", cond, whereString)
-		vals = append(vals, whereVals...)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.writeString("UPDATE ")
+	buf.writeString(quoteField(table))
+	buf.writeString(" SET ")
+	for i, k := range keys {
+		if i > 0 {
+			buf.writeByte(',')
+		}
+		buf.writeString(quoteField(k))
+		buf.writeByte('=')
+		buf.writePlaceholder(&placeHolderIndex)
 	}
-	return cond, vals, nil
+	vals = appendWhere(buf, " WHERE ", &placeHolderIndex, vals, conditions...)
+	return buf.String(), vals, nil
 }
 
 func buildDelete(table string, conditions ...Comparable) (string, []interface{}, error) {
 	var placeHolderIndex int
-	whereString, vals := whereConnector(&placeHolderIndex, conditions...)
-	if "" == whereString {
-		return fmt.Sprintf("DELETE FROM %s", table), nil, nil
-	}
-	format := "DELETE FROM %s WHERE %s"
-
-	cond := fmt.Sprintf(format, quoteField(table), whereString)
-	return cond, vals, nil
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.writeString("DELETE FROM ")
+	buf.writeString(quoteField(table))
+	vals := appendWhere(buf, " WHERE ", &placeHolderIndex, nil, conditions...)
+	return buf.String(), vals, nil
 }
 
 func splitCondition(conditions []Comparable) ([]Comparable, []Comparable) {
@@ -294,38 +418,46 @@ func splitCondition(conditions []Comparable) ([]Comparable, []Comparable) {
 }
 
 func buildSelect(table string, ufields []string, groupBy string, uOrderBy []eleOrderBy, limit *eleLimit, conditions ...Comparable) (string, []interface{}, error) {
-	var placeHolderIndex int
-	format := "SELECT %s FROM %s"
-	fields := "*"
+	return buildSelectAt(0, table, ufields, groupBy, uOrderBy, limit, conditions...)
+}
+
+// buildSelectAt is buildSelect with the placeholders of conditions numbered after placeHolderIndex
+func buildSelectAt(placeHolderIndex int, table string, ufields []string, groupBy string, uOrderBy []eleOrderBy, limit *eleLimit, conditions ...Comparable) (string, []interface{}, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.writeString("SELECT ")
 	if len(ufields) > 0 {
-		for i := range ufields {
-			ufields[i] = quoteField(ufields[i])
+		for i, field := range ufields {
+			if i > 0 {
+				buf.writeByte(',')
+			}
+			buf.writeString(quoteField(field))
 		}
-		fields = strings.Join(ufields, ",")
+	} else {
+		buf.writeByte('*')
 	}
-	cond := fmt.Sprintf(format, fields, quoteField(table))
+	buf.writeString(" FROM ")
+	buf.writeString(quoteField(table))
 	where, having := splitCondition(conditions)
-	whereString, vals := whereConnector(&placeHolderIndex, where...)
-	if "" != whereString {
-		cond = fmt.Sprintf("%s WHERE %s", cond, whereString)
-	}
+	vals := appendWhere(buf, " WHERE ", &placeHolderIndex, nil, where...)
 	if "" != groupBy {
-		cond = fmt.Sprintf("%s GROUP BY %s", cond, quoteField(groupBy))
+		buf.writeString(" GROUP BY ")
+		buf.writeString(quoteField(groupBy))
 	}
 	if nil != having {
-		havingString, havingVals := whereConnector(&placeHolderIndex, having...)
-		cond = fmt.Sprintf("%s HAVING %s", cond, havingString)
-		vals = append(vals, havingVals...)
+		vals = appendWhere(buf, " HAVING ", &placeHolderIndex, vals, having...)
 	}
 	if len(uOrderBy) != 0 {
-		str, err := orderBy(uOrderBy)
-		if nil != err {
+		buf.writeString(" ORDER BY ")
+		if err := appendOrderBy(buf, uOrderBy); nil != err {
 			return "", nil, err
 		}
-		cond = fmt.Sprintf("%s ORDER BY %s", cond, str)
 	}
 	if nil != limit {
-		cond = fmt.Sprintf("%s LIMIT %d OFFSET %d", cond, limit.begin, limit.step)
+		buf.writeString(" LIMIT ")
+		buf.writeUint(uint64(limit.begin))
+		buf.writeString(" OFFSET ")
+		buf.writeUint(uint64(limit.step))
 	}
-	return cond, vals, nil
+	return buf.String(), vals, nil
 }
@@ -0,0 +1,84 @@
+package builder
+
+import (
+	"context"
+
+	"github.com/didi/gendry/scanner"
+)
+
+// SelectInto executes the query built by BuildSelect and scans the result into dest,
+// which is the same as the target of scanner.Scan. the rows are always closed
+func SelectInto(ctx context.Context, db Executor, table string, where interface{}, fields []string, dest interface{}) error {
+	cond, vals, err := selectFrom(table, where, fields, 0)
+	if nil != err {
+		return err
+	}
+	return scanner.Query(ctx, db, dest, cond, vals...)
+}
+
+// GetOne is the same as SelectInto but only fetches the first row into dest, which must be a pointer to struct.
+// _limit of where is replaced, scanner.ErrEmptyResult is returned if there's no row
+func GetOne(ctx context.Context, db Executor, table string, where interface{}, fields []string, dest interface{}) error {
+	where, err := withKeys(where, KV{"_limit", []uint{1, 0}})
+	if nil != err {
+		return err
+	}
+	return SelectInto(ctx, db, table, where, fields, dest)
+}
+
+// Exists reports whether any row matches where
+func Exists(ctx context.Context, db Executor, table string, where interface{}) (bool, error) {
+	where, err := withoutKeys(where, "_orderby")
+	if nil != err {
+		return false, err
+	}
+	if where, err = withKeys(where, KV{"_limit", []uint{1, 0}}); nil != err {
+		return false, err
+	}
+	cond, vals, err := selectFrom(table, where, []string{"1"}, 0)
+	if nil != err {
+		return false, err
+	}
+	rows, err := db.QueryContext(ctx, cond, vals...)
+	if nil != err {
+		return false, err
+	}
+	defer rows.Close()
+	exists := rows.Next()
+	return exists, rows.Err()
+}
+
+// InsertRows executes the statement built by BuildInsert and returns the number of rows affected
+func InsertRows(ctx context.Context, db Executor, table string, data []map[string]interface{}) (int64, error) {
+	cond, vals, err := BuildInsert(table, data)
+	if nil != err {
+		return 0, err
+	}
+	return execAffected(ctx, db, cond, vals)
+}
+
+// UpdateWhere executes the statement built by BuildUpdate and returns the number of rows affected
+func UpdateWhere(ctx context.Context, db Executor, table string, where interface{}, update map[string]interface{}) (int64, error) {
+	cond, vals, err := updateWhere(table, where, update)
+	if nil != err {
+		return 0, err
+	}
+	return execAffected(ctx, db, cond, vals)
+}
+
+// DeleteWhere executes the statement built by BuildDelete and returns the number of rows affected
+func DeleteWhere(ctx context.Context, db Executor, table string, where interface{}) (int64, error) {
+	cond, vals, err := deleteWhere(table, where)
+	if nil != err {
+		return 0, err
+	}
+	return execAffected(ctx, db, cond, vals)
+}
+
+func execAffected(ctx context.Context, db Executor, cond string, vals []interface{}) (int64, error) {
+	result, err := db.ExecContext(ctx, cond, vals...)
+	if nil != err {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/didi/gendry/scanner"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestSelectInto(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// a leaked rows would hold the only connection and block the next query
+	db.SetMaxOpenConns(1)
+	ass := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	fields := []string{"id", "name"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (name=$1)")).WithArgs("a").
+		WillReturnRows(sqlmock.NewRows(fields).AddRow(int64(1), "a").AddRow(int64(2), "a"))
+	var items []pageItem
+	ass.NoError(SelectInto(ctx, db, "tb", map[string]interface{}{"name": "a"}, fields, &items))
+	ass.Equal([]pageItem{{1, "a"}, {2, "a"}}, items)
+
+	// scan error
+	mock.ExpectQuery("SELECT id,name FROM tb").
+		WillReturnRows(sqlmock.NewRows(fields).AddRow("x", "a"))
+	ass.Error(SelectInto(ctx, db, "tb", nil, fields, &items))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (id=$1) LIMIT 1 OFFSET 0")).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(fields).AddRow(int64(2), "b"))
+	var item pageItem
+	ass.NoError(GetOne(ctx, db, "tb", map[string]interface{}{"id": 2, "_limit": []uint{10, 0}}, fields, &item))
+	ass.Equal(pageItem{2, "b"}, item)
+
+	mock.ExpectQuery("LIMIT 1 OFFSET 0").WillReturnRows(sqlmock.NewRows(fields))
+	ass.Equal(scanner.ErrEmptyResult, GetOne(ctx, db, "tb", OrderedWhere{{"id", 3}}, fields, &item))
+
+	_, err = Exists(ctx, db, "tb", 1)
+	ass.Equal(errWhereType, err)
+	ass.Equal(errWhereType, SelectInto(ctx, db, "tb", 1, nil, &items))
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+func TestExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ass := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM tb WHERE (name=$1) LIMIT 1 OFFSET 0")).WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(int64(1)))
+	exists, err := Exists(ctx, db, "tb", map[string]interface{}{"name": "a", "_orderby": "id desc"})
+	ass.NoError(err)
+	ass.True(exists)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM tb LIMIT 1 OFFSET 0")).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	exists, err = Exists(ctx, db, "tb", nil)
+	ass.NoError(err)
+	ass.False(exists)
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+func TestExecHelpers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tb (age,name) VALUES ($1,$2),($3,$4)")).
+		WithArgs(1, "a", 2, "b").WillReturnResult(sqlmock.NewResult(2, 2))
+	affected, err := InsertRows(ctx, db, "tb", []map[string]interface{}{{"name": "a", "age": 1}, {"name": "b", "age": 2}})
+	ass.NoError(err)
+	ass.Equal(int64(2), affected)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE tb SET age=$1 WHERE (name=$2)")).
+		WithArgs(3, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err = UpdateWhere(ctx, db, "tb", map[string]interface{}{"name": "a"}, map[string]interface{}{"age": 3})
+	ass.NoError(err)
+	ass.Equal(int64(1), affected)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tb WHERE (age>$1)")).
+		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 4))
+	affected, err = DeleteWhere(ctx, db, "tb", map[string]interface{}{"age >": 1})
+	ass.NoError(err)
+	ass.Equal(int64(4), affected)
+
+	execErr := errors.New("deadlock")
+	mock.ExpectExec("DELETE FROM tb").WillReturnError(execErr)
+	_, err = DeleteWhere(ctx, db, "tb", nil)
+	ass.Equal(execErr, err)
+
+	_, err = InsertRows(ctx, db, "tb", nil)
+	ass.Error(err)
+	ass.NoError(mock.ExpectationsWereMet())
+}
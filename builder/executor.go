@@ -0,0 +1,20 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor is the subset of *sql.DB, *sql.Tx and *sql.Conn the helpers of builder execute statements with,
+// so they work the same inside a transaction, on a pinned connection or with a test double
+type Executor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var (
+	_ Executor = (*sql.DB)(nil)
+	_ Executor = (*sql.Tx)(nil)
+	_ Executor = (*sql.Conn)(nil)
+)
@@ -0,0 +1,46 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestExecutor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM tb").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(int64(3)))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM tb").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(int64(3)))
+	mock.ExpectQuery("SELECT id,name FROM tb LIMIT 2 OFFSET 0").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a").AddRow(int64(2), "b"))
+	mock.ExpectRollback()
+	tx, err := db.Begin()
+	ass.NoError(err)
+	result, err := AggregateQuery(ctx, tx, "tb", nil, AggregateCount("*"))
+	ass.NoError(err)
+	ass.Equal(int64(3), result.Int64())
+	var items []pageItem
+	p, err := Paginate(ctx, tx, "tb", nil, []string{"id", "name"}, 1, 2, &items)
+	ass.NoError(err)
+	ass.Equal(int64(2), p.PageCount)
+	ass.Len(items, 2)
+	ass.NoError(tx.Rollback())
+
+	conn, err := db.Conn(ctx)
+	ass.NoError(err)
+	defer conn.Close()
+	mock.ExpectQuery("SELECT max\\(id\\) FROM tb").WillReturnRows(sqlmock.NewRows([]string{"max(id)"}).AddRow(int64(9)))
+	result, err = AggregateQuery(ctx, conn, "tb", nil, AggregateMax("id"))
+	ass.NoError(err)
+	ass.Equal(int64(9), result.Int64())
+	ass.NoError(mock.ExpectationsWereMet())
+}
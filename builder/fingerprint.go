@@ -0,0 +1,39 @@
+package builder
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	fpPlaceholder = regexp.MustCompile(`\$\d+`)
+	fpSpaces      = regexp.MustCompile(`\s+`)
+	fpLimit       = regexp.MustCompile(`(?i)\b(LIMIT|OFFSET)\s+\d+`)
+	// only the lists of IN and the rows of VALUES collapse, a function call like coalesce($1,$2) is kept
+	fpList   = regexp.MustCompile(`(?i)\b(IN)\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fpValues = regexp.MustCompile(`(?i)\b(VALUES)\s*\(\s*\?(?:\s*,\s*\?)*\s*\)(?:\s*,\s*\(\s*\?(?:\s*,\s*\?)*\s*\))*`)
+)
+
+// NormalizeQuery rewrites a query into a shape that doesn't depend on the values bound to it.
+// placeholders become ?, lists of placeholders after IN such as IN ($1,$2,$3) become (...),
+// multi-row VALUES collapse into a single (...) and the numbers after LIMIT/OFFSET become ?.
+// so queries produced by BuildSelect,BuildInsert or NamedQuery with different list sizes
+// share the same normalized form.
+func NormalizeQuery(sql string) string {
+	sql = strings.TrimSpace(fpSpaces.ReplaceAllString(sql, " "))
+	sql = fpPlaceholder.ReplaceAllString(sql, "?")
+	sql = fpLimit.ReplaceAllString(sql, "$1 ?")
+	sql = fpList.ReplaceAllString(sql, "$1 (...)")
+	sql = fpValues.ReplaceAllString(sql, "$1 (...)")
+	return sql
+}
+
+// Fingerprint returns a stable hash of the normalized query(see NormalizeQuery)
+// it's suitable for labeling metrics or grouping slow queries
+func Fingerprint(sql string) string {
+	h := fnv.New64a()
+	h.Write([]byte(NormalizeQuery(sql)))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
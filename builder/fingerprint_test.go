@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	var data = []struct {
+		in  string
+		out string
+	}{
+		{
+			in:  "SELECT * FROM tb WHERE (name=$1 AND age IN ($2,$3,$4))",
+			out: "SELECT * FROM tb WHERE (name=? AND age IN (...))",
+		},
+		{
+			in:  "INSERT INTO tb (age,name) VALUES ($1,$2),($3,$4),($5,$6)",
+			out: "INSERT INTO tb (age,name) VALUES (...)",
+		},
+		{
+			in:  "SELECT * FROM tb WHERE (age>$1)  ORDER BY age DESC LIMIT 10 OFFSET 20",
+			out: "SELECT * FROM tb WHERE (age>?) ORDER BY age DESC LIMIT ? OFFSET ?",
+		},
+		{
+			in:  "select * from tb where name=$1 and score in ($2,$3)",
+			out: "select * from tb where name=? and score in (...)",
+		},
+		{
+			in:  "SELECT * FROM tb WHERE (lower(name)=lower($1) AND coalesce(age,$2)>$3 AND id NOT IN($4))",
+			out: "SELECT * FROM tb WHERE (lower(name)=lower(?) AND coalesce(age,?)>? AND id NOT IN (...))",
+		},
+		{
+			in:  "INSERT INTO tb (age,name) values ($1, $2) , ($3,$4)",
+			out: "INSERT INTO tb (age,name) values (...)",
+		},
+	}
+	ass := assert.New(t)
+	for _, tc := range data {
+		ass.Equal(tc.out, NormalizeQuery(tc.in))
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	ass := assert.New(t)
+	cond1, _, err := BuildSelect("tb", map[string]interface{}{
+		"name":   "deen",
+		"age in": []interface{}{1, 2, 3},
+	}, nil)
+	ass.NoError(err)
+	cond2, _, err := BuildSelect("tb", map[string]interface{}{
+		"name":   "caibirdme",
+		"age in": []interface{}{1},
+	}, nil)
+	ass.NoError(err)
+	ass.NotEqual(cond1, cond2)
+	ass.Equal(Fingerprint(cond1), Fingerprint(cond2))
+
+	ins1, _, err := BuildInsert("tb", []map[string]interface{}{{"name": "a"}})
+	ass.NoError(err)
+	ins2, _, err := BuildInsert("tb", []map[string]interface{}{{"name": "a"}, {"name": "b"}})
+	ass.NoError(err)
+	ass.Equal(Fingerprint(ins1), Fingerprint(ins2))
+
+	ass.NotEqual(Fingerprint(cond1), Fingerprint(ins1))
+	ass.NotEqual(Fingerprint("SELECT * FROM tb WHERE (name=lower($1))"), Fingerprint("SELECT * FROM tb WHERE (name=coalesce($1,$2))"))
+}
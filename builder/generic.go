@@ -0,0 +1,51 @@
+package builder
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/didi/gendry/scanner"
+)
+
+// Fields returns the columns the default scanner binds the fields of T to, T must be a struct or a pointer to struct
+func Fields[T any]() ([]string, error) {
+	return structColumns(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// Select is the same as BuildSelect but selects the columns of Fields[T]
+func Select[T any](table string, where interface{}) (string, []interface{}, error) {
+	fields, err := Fields[T]()
+	if nil != err {
+		return "", nil, err
+	}
+	return selectFrom(table, where, fields, 0)
+}
+
+// SelectAll executes the query built by Select and returns the rows as []T
+func SelectAll[T any](ctx context.Context, db Executor, table string, where interface{}) ([]T, error) {
+	cond, vals, err := Select[T](table, where)
+	if nil != err {
+		return nil, err
+	}
+	var result []T
+	if err = scanner.Query(ctx, db, &result, cond, vals...); nil != err {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SelectOne is the same as SelectAll but only fetches the first row into a T, which must be a struct.
+// scanner.ErrEmptyResult is returned if there's no row
+func SelectOne[T any](ctx context.Context, db Executor, table string, where interface{}) (T, error) {
+	var result T
+	where, err := withKeys(where, KV{"_limit", []uint{1, 0}})
+	if nil != err {
+		return result, err
+	}
+	cond, vals, err := Select[T](table, where)
+	if nil != err {
+		return result, err
+	}
+	err = scanner.Query(ctx, db, &result, cond, vals...)
+	return result, err
+}
@@ -0,0 +1,121 @@
+package builder
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/didi/gendry/scanner"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type genericBase struct {
+	ID int64 `ddb:"id"`
+}
+
+type genericUser struct {
+	*genericBase
+	Name  string `ddb:"name"`
+	Age   int    `ddb:"age,omitempty"`
+	Extra string
+	Skip  string `ddb:"-"`
+}
+
+func TestFieldsAndSelect(t *testing.T) {
+	ass := assert.New(t)
+	fields, err := Fields[genericUser]()
+	ass.NoError(err)
+	// the embedded pointer of an unexported type can't be scanned into
+	ass.Equal([]string{"name", "age"}, fields)
+	fields, err = Fields[*pageItem]()
+	ass.NoError(err)
+	ass.Equal([]string{"id", "name"}, fields)
+	_, err = Fields[int]()
+	ass.Equal(errNoneStructTarget, err)
+
+	cond, vals, err := Select[pageItem]("tb", map[string]interface{}{"name": "a"})
+	ass.NoError(err)
+	ass.Equal("SELECT id,name FROM tb WHERE (name=$1)", cond)
+	ass.Equal([]interface{}{"a"}, vals)
+	_, _, err = Select[[]pageItem]("tb", nil)
+	ass.Equal(errNoneStructTarget, err)
+}
+
+func TestFieldsFollowScanner(t *testing.T) {
+	type account struct {
+		ID       int64 `db:"uid,pk"`
+		UserName string
+		Skipped  string `db:"-"`
+		Other    string `ddb:"other"`
+	}
+	ass := assert.New(t)
+	scanner.SetDefault(scanner.New(scanner.WithTagName("db"), scanner.WithNameMapper(scanner.SnakeCase)))
+	defer scanner.SetDefault(nil)
+	fields, err := Fields[account]()
+	ass.NoError(err)
+	ass.Equal([]string{"uid", "user_name", "other"}, fields)
+	cond, vals, err := BuildUpdateStruct("tb", account{ID: 1, UserName: "deen"})
+	ass.NoError(err)
+	ass.Equal("UPDATE tb SET other=$1,user_name=$2 WHERE (uid=$3)", cond)
+	ass.Equal([]interface{}{"", "deen", int64(1)}, vals)
+}
+
+// the columns selected for a struct are the ones scanner binds to its fields
+func TestSelectScanRoundTrip(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	type tracedPost struct {
+		genericBase
+		post
+	}
+	fields, err := Fields[tracedPost]()
+	ass.NoError(err)
+	ass.Equal([]string{"id", "title", "author_id", "author_name", "editor_id", "editor_name", "created"}, fields)
+	cond, _, err := Select[tracedPost]("posts", nil)
+	ass.NoError(err)
+	ass.Equal("SELECT id,title,author_id,author_name,editor_id,editor_name,created FROM posts", cond)
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(cond)).WillReturnRows(sqlmock.NewRows(fields).
+		AddRow(int64(1), "hi", int64(2), "deen", int64(3), "tony", now))
+	var posts []tracedPost
+	ass.NoError(scanner.New(scanner.WithStrict(true)).Query(context.Background(), db, &posts, cond))
+	ass.Equal([]tracedPost{{genericBase{1}, post{"hi", author{2, "deen"}, &author{3, "tony"}, now}}}, posts)
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+func TestSelectAllAndOne(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (name=$1)")).WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a").AddRow(int64(2), "a"))
+	items, err := SelectAll[pageItem](ctx, db, "tb", map[string]interface{}{"name": "a"})
+	ass.NoError(err)
+	ass.Equal([]pageItem{{1, "a"}, {2, "a"}}, items)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (id=$1) LIMIT 1 OFFSET 0")).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(2), "b"))
+	item, err := SelectOne[pageItem](ctx, db, "tb", map[string]interface{}{"id": 2})
+	ass.NoError(err)
+	ass.Equal(pageItem{2, "b"}, item)
+
+	mock.ExpectQuery("LIMIT 1 OFFSET 0").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	_, err = SelectOne[pageItem](ctx, db, "tb", nil)
+	ass.Equal(scanner.ErrEmptyResult, err)
+
+	_, err = SelectAll[int](ctx, db, "tb", nil)
+	ass.Equal(errNoneStructTarget, err)
+	ass.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,189 @@
+package builder
+
+import (
+	"errors"
+	"reflect"
+)
+
+var (
+	errWhereType = errors.New("[builder] where must be a map[string]interface{} or an OrderedWhere")
+)
+
+// KV is a key-value pair of an OrderedWhere
+type KV struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedWhere is a where-condition which is rendered exactly in the given order,
+// instead of grouping the conditions by operator and sorting them by field like a where map.
+// it's accepted by the *Ordered builders and every helper taking where as an interface{},
+// keys and values follow the same rules as the map. ie: OrderedWhere{{"tenant_id", 1}, {"created_at >", t}, {"_orderby", "created_at desc"}}
+type OrderedWhere []KV
+
+var orderedWhereType = reflect.TypeOf(OrderedWhere(nil))
+
+// normalizeWhere converts a named where type(ie: type Where map[string]interface{}) into
+// a map[string]interface{} or an OrderedWhere, anything else is returned as is
+func normalizeWhere(where interface{}) interface{} {
+	switch where.(type) {
+	case nil, map[string]interface{}, OrderedWhere:
+		return where
+	}
+	v := reflect.ValueOf(where)
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m
+	case v.Type().ConvertibleTo(orderedWhereType):
+		return v.Convert(orderedWhereType).Interface()
+	}
+	return where
+}
+
+// rangeWhere calls fn for every key-value pair of a where map or an OrderedWhere,
+// keys of a map are visited in sorted order
+func rangeWhere(where interface{}, fn func(key string, val interface{}) error) error {
+	switch w := normalizeWhere(where).(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for _, k := range sortedMapKeys(w) {
+			if err := fn(k, w[k]); nil != err {
+				return err
+			}
+		}
+	case OrderedWhere:
+		for _, kv := range w {
+			if err := fn(kv.Key, kv.Value); nil != err {
+				return err
+			}
+		}
+	default:
+		return errWhereType
+	}
+	return nil
+}
+
+func isSpecialKey(key string) bool {
+	switch key {
+	case "_orderby", "_groupby", "_having", "_limit":
+		return true
+	}
+	return false
+}
+
+// splitWhere separates the special keys BuildSelect understands from the conditions,
+// the conditions are returned in the same type as where
+func splitWhere(where interface{}) (map[string]interface{}, interface{}, error) {
+	special := make(map[string]interface{})
+	switch w := normalizeWhere(where).(type) {
+	case nil:
+		return special, nil, nil
+	case map[string]interface{}:
+		rest := make(map[string]interface{}, len(w))
+		for k, v := range w {
+			if isSpecialKey(k) {
+				special[k] = v
+			} else {
+				rest[k] = v
+			}
+		}
+		return special, rest, nil
+	case OrderedWhere:
+		rest := make(OrderedWhere, 0, len(w))
+		for _, kv := range w {
+			if isSpecialKey(kv.Key) {
+				special[kv.Key] = kv.Value
+			} else {
+				rest = append(rest, kv)
+			}
+		}
+		return special, rest, nil
+	}
+	return nil, nil, errWhereType
+}
+
+type orderedCondition struct {
+	field, operator string
+	val             interface{}
+	// values of an "in" condition
+	vals []interface{}
+}
+
+// orderedComparable renders the conditions of an OrderedWhere one by one
+type orderedComparable []orderedCondition
+
+func newOrderedComparable(where OrderedWhere) (orderedComparable, error) {
+	o := make(orderedComparable, 0, len(where))
+	for _, kv := range where {
+		field, operator, err := splitKey(kv.Key)
+		if nil != err {
+			return nil, err
+		}
+		if !isStringInSlice(operator, opOrder) {
+			return nil, ErrUnsupportedOperator
+		}
+		c := orderedCondition{field: field, operator: operator, val: kv.Value}
+		if operator == opIn {
+			vals, ok := convertInterfaceToMap(kv.Value)
+			if !ok {
+				return nil, errWhereInType
+			}
+			if 0 == len(vals) {
+				return nil, errEmptyINCondition
+			}
+			c.vals = vals
+		}
+		o = append(o, c)
+	}
+	return o, nil
+}
+
+// Build implements the Comparable interface
+func (o orderedComparable) Build(placeHolderIndex *int) ([]string, []interface{}) {
+	if 0 == len(o) {
+		return nil, nil
+	}
+	cond := make([]string, len(o))
+	var vals []interface{}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	for i, c := range o {
+		buf.b = buf.b[:0]
+		vals = c.appendTo(buf, placeHolderIndex, vals)
+		cond[i] = buf.String()
+	}
+	return cond, vals
+}
+
+func (o orderedComparable) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	for _, c := range o {
+		buf.and()
+		vals = c.appendTo(buf, placeHolderIndex, vals)
+	}
+	return vals
+}
+
+func (c orderedCondition) appendTo(buf *sqlBuffer, placeHolderIndex *int, vals []interface{}) []interface{} {
+	switch c.operator {
+	case opIn:
+		appendIn(buf, c.field, len(c.vals), placeHolderIndex)
+		return append(vals, c.vals...)
+	case opLike:
+		buf.writeString(c.field)
+		buf.writeString(" LIKE ")
+	case opNe2:
+		buf.writeString(quoteField(c.field))
+		buf.writeString(opNe1)
+	default:
+		buf.writeString(quoteField(c.field))
+		buf.writeString(c.operator)
+	}
+	buf.writePlaceholder(placeHolderIndex)
+	return append(vals, c.val)
+}
@@ -0,0 +1,148 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedWhere_BuildSelect(t *testing.T) {
+	var data = []struct {
+		where OrderedWhere
+		cond  string
+		vals  []interface{}
+		err   error
+	}{
+		{
+			where: OrderedWhere{
+				{"tenant_id", 1},
+				{"status in", []string{"new", "paid"}},
+				{"created_at >", "2018-01-01"},
+				{"name like", "%deen%"},
+				{"role <>", "admin"},
+				{"_orderby", "created_at desc"},
+				{"_limit", []uint{10, 0}},
+			},
+			cond: "SELECT * FROM tb WHERE (tenant_id=$1 AND status IN ($2,$3) AND created_at>$4 AND name LIKE $5 AND role!=$6) ORDER BY created_at DESC LIMIT 10 OFFSET 0",
+			vals: []interface{}{1, "new", "paid", "2018-01-01", "%deen%", "admin"},
+		},
+		{
+			where: OrderedWhere{
+				{"age >", 10},
+				{"age <", 20},
+				{"_groupby", "name"},
+				{"_having", OrderedWhere{{"total <", 50}, {"total >", 5}}},
+			},
+			cond: "SELECT * FROM tb WHERE (age>$1 AND age<$2) GROUP BY name HAVING (total<$3 AND total>$4)",
+			vals: []interface{}{10, 20, 50, 5},
+		},
+		{
+			where: OrderedWhere{},
+			cond:  "SELECT * FROM tb",
+		},
+		{
+			where: OrderedWhere{{"age ~", 1}},
+			err:   ErrUnsupportedOperator,
+		},
+		{
+			where: OrderedWhere{{"age in", 1}},
+			err:   errWhereInType,
+		},
+		{
+			where: OrderedWhere{{"age in", []int{}}},
+			err:   errEmptyINCondition,
+		},
+		{
+			where: OrderedWhere{{"_groupby", "name"}, {"_having", OrderedWhere{{"total ~", 1}}}},
+			err:   errHavingUnsupportedOperator,
+		},
+	}
+	ass := assert.New(t)
+	for idx, tc := range data {
+		cond, vals, err := BuildSelectOrdered("tb", tc.where, nil)
+		ass.Equal(tc.err, err, "idx:%d", idx)
+		ass.Equal(tc.cond, cond, "idx:%d", idx)
+		ass.Equal(tc.vals, vals, "idx:%d", idx)
+	}
+}
+
+func TestOrderedWhere_BuildUpdateDelete(t *testing.T) {
+	ass := assert.New(t)
+	where := OrderedWhere{{"name", "deen"}, {"age >", 10}, {"id in", []int{1, 2}}}
+	cond, vals, err := BuildUpdateOrdered("tb", where, map[string]interface{}{"role": "driver"})
+	ass.NoError(err)
+	ass.Equal("UPDATE tb SET role=$1 WHERE (name=$2 AND age>$3 AND id IN ($4,$5))", cond)
+	ass.Equal([]interface{}{"driver", "deen", 10, 1, 2}, vals)
+
+	cond, vals, err = BuildDeleteOrdered("tb", where)
+	ass.NoError(err)
+	ass.Equal("DELETE FROM tb WHERE (name=$1 AND age>$2 AND id IN ($3,$4))", cond)
+	ass.Equal([]interface{}{"deen", 10, 1, 2}, vals)
+}
+
+func TestOrderedComparable(t *testing.T) {
+	ass := assert.New(t)
+	o, err := newOrderedComparable(OrderedWhere{{"b", 1}, {"a in", []int{2, 3}}, {"c like", "x%"}})
+	ass.NoError(err)
+	var placeHolderIndex int
+	cond, vals := o.Build(&placeHolderIndex)
+	ass.Equal([]string{"b=$1", "a IN ($2,$3)", "c LIKE $4"}, cond)
+	ass.Equal([]interface{}{1, 2, 3, "x%"}, vals)
+}
+
+func TestOrderedWhere_SchemaAndCache(t *testing.T) {
+	ass := assert.New(t)
+	schema := NewSchema(NewColumn("name", ""), NewColumn("age", 0))
+	ass.NoError(schema.ValidateOrdered(OrderedWhere{{"name", "deen"}, {"_orderby", "age desc"}}))
+	ass.Equal(SchemaErr{"role", `column "role" is not allowed`}, schema.ValidateOrdered(OrderedWhere{{"role", "x"}}))
+	ass.Equal(errWhereType, schema.validate(10))
+
+	cache := NewCache(10)
+	for i := 0; i < 3; i++ {
+		where := OrderedWhere{{"name", "deen"}, {"age in", []int{i, i + 1}}, {"name", "tony"}}
+		cond, vals, err := cache.BuildSelectOrdered("tb", where, nil)
+		ass.NoError(err)
+		ass.Equal("SELECT * FROM tb WHERE (name=$1 AND age IN ($2,$3) AND name=$4)", cond)
+		ass.Equal([]interface{}{"deen", i, i + 1, "tony"}, vals)
+	}
+	cond, vals, err := cache.BuildSelect("tb", map[string]interface{}{"name": "deen", "age in": []int{1, 2}}, nil)
+	ass.NoError(err)
+	ass.Equal("SELECT * FROM tb WHERE (name=$1 AND age IN ($2,$3))", cond)
+	ass.Equal([]interface{}{"deen", 1, 2}, vals)
+	ass.Equal(CacheStats{Hits: 2, Misses: 2, Size: 2}, cache.Stats())
+}
+
+type namedWhere map[string]interface{}
+
+type namedOrderedWhere []KV
+
+func TestNamedWhere(t *testing.T) {
+	ass := assert.New(t)
+	where := namedWhere{"name": "deen", "age >": 10, "_groupby": "name", "_having": namedWhere{"total >": 5}}
+	cond, vals, err := BuildSelect("tb", where, nil)
+	ass.NoError(err)
+	ass.Equal("SELECT * FROM tb WHERE (name=$1 AND age>$2) GROUP BY name HAVING (total>$3)", cond)
+	ass.Equal([]interface{}{"deen", 10, 5}, vals)
+
+	cond, vals, err = BuildCount("tb", namedWhere{"name": "deen", "_limit": []uint{0, 10}})
+	ass.NoError(err)
+	ass.Equal("SELECT count(*) FROM tb WHERE (name=$1)", cond)
+	ass.Equal([]interface{}{"deen"}, vals)
+
+	cond, vals, err = countWhere("tb", map[string]string{"name": "deen"})
+	ass.NoError(err)
+	ass.Equal("SELECT count(*) FROM tb WHERE (name=$1)", cond)
+	ass.Equal([]interface{}{"deen"}, vals)
+
+	cond, vals, err = countWhere("tb", namedOrderedWhere{{"name", "deen"}, {"age >", 10}})
+	ass.NoError(err)
+	ass.Equal("SELECT count(*) FROM tb WHERE (name=$1 AND age>$2)", cond)
+	ass.Equal([]interface{}{"deen", 10}, vals)
+
+	ass.NoError(NewSchema(NewColumn("name", "")).Validate(namedWhere{"name": "deen"}))
+
+	_, _, err = countWhere("tb", []string{"age"})
+	ass.Equal(errWhereType, err)
+	_, _, err = countWhere("tb", map[int]interface{}{1: "deen"})
+	ass.Equal(errWhereType, err)
+}
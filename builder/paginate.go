@@ -0,0 +1,254 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/didi/gendry/scanner"
+)
+
+var (
+	errPageSize         = errors.New("[builder] page size must be greater than 0")
+	errPaginateTarget   = errors.New("[builder] dest must be a pointer to a slice of struct")
+	errKeysetColumn     = errors.New("[builder] keyset column must be set")
+	errKeysetDirection  = errors.New("[builder] keyset can't have both After and Before")
+	errKeysetColumnType = errors.New("[builder] dest has no field tagged with the keyset column")
+)
+
+// Pagination is the metadata of a page returned by Paginate and PaginateKeyset
+type Pagination struct {
+	// Page is the 1-based page number, it's 0 for PaginateKeyset
+	Page      uint
+	PageSize  uint
+	Total     int64
+	PageCount int64
+	// Next and Prev are the cursors of the adjacent pages, nil means there's no such page.
+	// they're page numbers(uint) for Paginate, and values of the keyset column for PaginateKeyset
+	Next interface{}
+	Prev interface{}
+}
+
+// Keyset describes a page of PaginateKeyset
+type Keyset struct {
+	// Column must be unique and sortable, and it must be selected
+	Column string
+	// Value returns the value of Column of an element of dest, which is the cursor of Next and Prev.
+	// nil reads the field the default scanner binds Column to, set it if dest is scanned by another Scanner
+	Value func(item interface{}) interface{}
+	// After returns the rows whose Column is greater than After
+	After interface{}
+	// Before returns the rows whose Column is less than Before
+	Before interface{}
+}
+
+// withKeys returns a copy of where with kvs set, an existing special key(ie: _limit) is replaced
+// and a condition is ANDed with the caller's ones, a map with a condition of the same key
+// is turned into an OrderedWhere so neither of them is dropped
+func withKeys(where interface{}, kvs ...KV) (interface{}, error) {
+	var keys []string
+	for _, kv := range kvs {
+		if isSpecialKey(kv.Key) {
+			keys = append(keys, kv.Key)
+		}
+	}
+	copied, err := withoutKeys(where, keys...)
+	if nil != err {
+		return nil, err
+	}
+	switch w := copied.(type) {
+	case OrderedWhere:
+		return append(w, kvs...), nil
+	case map[string]interface{}:
+		for _, kv := range kvs {
+			if _, ok := w[kv.Key]; ok {
+				ordered := make(OrderedWhere, 0, len(w)+len(kvs))
+				for _, k := range sortedMapKeys(w) {
+					ordered = append(ordered, KV{k, w[k]})
+				}
+				return append(ordered, kvs...), nil
+			}
+		}
+		for _, kv := range kvs {
+			w[kv.Key] = kv.Value
+		}
+		return w, nil
+	}
+	m := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m, nil
+}
+
+func checkPageTarget(dest interface{}) error {
+	t := reflect.TypeOf(dest)
+	if nil == t || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice || reflect.ValueOf(dest).IsNil() {
+		return errPaginateTarget
+	}
+	return nil
+}
+
+func queryCount(ctx context.Context, db Executor, table string, where interface{}) (int64, error) {
+	cond, vals, err := countWhere(table, where)
+	if nil != err {
+		return 0, err
+	}
+	var total int64
+	err = db.QueryRowContext(ctx, cond, vals...).Scan(&total)
+	return total, err
+}
+
+func queryPage(ctx context.Context, db Executor, table string, where interface{}, fields []string, dest interface{}) error {
+	cond, vals, err := selectFrom(table, where, fields, 0)
+	if nil != err {
+		return err
+	}
+	return queryScan(ctx, db, cond, vals, dest)
+}
+
+func queryScan(ctx context.Context, db Executor, cond string, vals []interface{}, dest interface{}) error {
+	return scanner.Query(ctx, db, dest, cond, vals...)
+}
+
+func pageCount(total int64, pageSize uint) int64 {
+	return (total + int64(pageSize) - 1) / int64(pageSize)
+}
+
+// Paginate scans the page-th(1-based) page of the query into dest, which must be a pointer to a slice of struct,
+// and returns the total number of rows and the page metadata.
+// _orderby of where is kept, _limit is replaced by the page
+func Paginate(ctx context.Context, db Executor, table string, where interface{}, fields []string, page, pageSize uint, dest interface{}) (*Pagination, error) {
+	if 0 == pageSize {
+		return nil, errPageSize
+	}
+	if err := checkPageTarget(dest); nil != err {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	total, err := queryCount(ctx, db, table, where)
+	if nil != err {
+		return nil, err
+	}
+	pageWhere, err := withKeys(where, KV{"_limit", []uint{pageSize, (page - 1) * pageSize}})
+	if nil != err {
+		return nil, err
+	}
+	if err = queryPage(ctx, db, table, pageWhere, fields, dest); nil != err {
+		return nil, err
+	}
+	p := &Pagination{
+		Page:      page,
+		PageSize:  pageSize,
+		Total:     total,
+		PageCount: pageCount(total, pageSize),
+	}
+	if int64(page) < p.PageCount {
+		p.Next = page + 1
+	}
+	if page > 1 {
+		p.Prev = page - 1
+	}
+	return p, nil
+}
+
+// PaginateKeyset is the same as Paginate but seeks the page by the value of the keyset column
+// instead of an offset, which stays fast on deep pages.
+// rows are ordered by the keyset column, the Next and Prev of the returned Pagination
+// can be used as the After and Before of the adjacent pages
+func PaginateKeyset(ctx context.Context, db Executor, table string, where interface{}, fields []string, keyset Keyset, pageSize uint, dest interface{}) (*Pagination, error) {
+	if 0 == pageSize {
+		return nil, errPageSize
+	}
+	if "" == keyset.Column {
+		return nil, errKeysetColumn
+	}
+	if nil != keyset.After && nil != keyset.Before {
+		return nil, errKeysetDirection
+	}
+	if err := checkPageTarget(dest); nil != err {
+		return nil, err
+	}
+	total, err := queryCount(ctx, db, table, where)
+	if nil != err {
+		return nil, err
+	}
+	backward := nil != keyset.Before
+	kvs := []KV{{"_limit", []uint{pageSize + 1, 0}}}
+	if backward {
+		kvs = append(kvs, KV{keyset.Column + " <", keyset.Before}, KV{"_orderby", keyset.Column + " desc"})
+	} else {
+		if nil != keyset.After {
+			kvs = append(kvs, KV{keyset.Column + " >", keyset.After})
+		}
+		kvs = append(kvs, KV{"_orderby", keyset.Column + " asc"})
+	}
+	pageWhere, err := withKeys(where, kvs...)
+	if nil != err {
+		return nil, err
+	}
+	if err = queryPage(ctx, db, table, pageWhere, fields, dest); nil != err {
+		return nil, err
+	}
+	items := reflect.ValueOf(dest).Elem()
+	hasMore := items.Len() > int(pageSize)
+	if hasMore {
+		items.Set(items.Slice(0, int(pageSize)))
+	}
+	if backward {
+		swap := reflect.Swapper(items.Interface())
+		for i, j := 0, items.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	p := &Pagination{
+		PageSize:  pageSize,
+		Total:     total,
+		PageCount: pageCount(total, pageSize),
+	}
+	if 0 == items.Len() {
+		return p, nil
+	}
+	first, err := keyset.value(items.Index(0))
+	if nil != err {
+		return nil, err
+	}
+	last, err := keyset.value(items.Index(items.Len() - 1))
+	if nil != err {
+		return nil, err
+	}
+	if (backward && hasMore) || (!backward && nil != keyset.After) {
+		p.Prev = first
+	}
+	if (!backward && hasMore) || backward {
+		p.Next = last
+	}
+	return p, nil
+}
+
+func (k Keyset) value(item reflect.Value) (interface{}, error) {
+	if nil != k.Value {
+		return k.Value(item.Interface()), nil
+	}
+	return keysetValue(item, k.Column)
+}
+
+func keysetValue(item reflect.Value, column string) (interface{}, error) {
+	for item.Kind() == reflect.Ptr {
+		if item.IsNil() {
+			return nil, errKeysetColumnType
+		}
+		item = item.Elem()
+	}
+	if item.Kind() != reflect.Struct {
+		return nil, errPaginateTarget
+	}
+	for _, f := range resolveStructFields(item) {
+		if f.tag.name == column {
+			return fieldValue(f.value), nil
+		}
+	}
+	return nil, errKeysetColumnType
+}
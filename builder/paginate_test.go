@@ -0,0 +1,128 @@
+package builder
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type pageItem struct {
+	ID   int64  `ddb:"id"`
+	Name string `ddb:"name"`
+}
+
+func TestPaginate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+	where := map[string]interface{}{"age >": 10, "_orderby": "id asc"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM tb WHERE (age>$1)")).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (age>$1) ORDER BY id ASC LIMIT 2 OFFSET 2")).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(3), "c").AddRow(int64(4), "d"))
+	var items []pageItem
+	p, err := Paginate(ctx, db, "tb", where, []string{"id", "name"}, 2, 2, &items)
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal([]pageItem{{3, "c"}, {4, "d"}}, items)
+	ass.Equal(&Pagination{Page: 2, PageSize: 2, Total: 5, PageCount: 3, Next: uint(3), Prev: uint(1)}, p)
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("LIMIT 2 OFFSET 0")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+	p, err = Paginate(ctx, db, "tb", nil, []string{"id", "name"}, 0, 2, &items)
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal([]pageItem{{1, "a"}}, items)
+	ass.Equal(&Pagination{Page: 1, PageSize: 2, Total: 1, PageCount: 1}, p)
+
+	_, err = Paginate(ctx, db, "tb", nil, nil, 1, 0, &items)
+	ass.Equal(errPageSize, err)
+	_, err = Paginate(ctx, db, "tb", nil, nil, 1, 10, items)
+	ass.Equal(errPaginateTarget, err)
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+	fields := []string{"id", "name"}
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (name=$1) ORDER BY id ASC LIMIT 3 OFFSET 0")).
+		WithArgs("x").
+		WillReturnRows(sqlmock.NewRows(fields).AddRow(int64(1), "x").AddRow(int64(2), "x").AddRow(int64(3), "x"))
+	var items []*pageItem
+	p, err := PaginateKeyset(ctx, db, "tb", OrderedWhere{{"name", "x"}}, fields, Keyset{Column: "id"}, 2, &items)
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal([]*pageItem{{1, "x"}, {2, "x"}}, items)
+	ass.Equal(&Pagination{PageSize: 2, Total: 5, PageCount: 3, Next: int64(2)}, p)
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (name=$1 AND id>$2) ORDER BY id ASC LIMIT 3 OFFSET 0")).
+		WithArgs("x", 4).
+		WillReturnRows(sqlmock.NewRows(fields).AddRow(int64(5), "x"))
+	p, err = PaginateKeyset(ctx, db, "tb", OrderedWhere{{"name", "x"}}, fields, Keyset{Column: "id", After: 4}, 2, &items)
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal([]*pageItem{{5, "x"}}, items)
+	ass.Equal(&Pagination{PageSize: 2, Total: 5, PageCount: 3, Prev: int64(5)}, p)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM tb WHERE (id>$1)")).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (id>$1 AND id>$2) ORDER BY id ASC LIMIT 3 OFFSET 0")).
+		WithArgs(100, 104).
+		WillReturnRows(sqlmock.NewRows(fields).AddRow(int64(105), "x"))
+	p, err = PaginateKeyset(ctx, db, "tb", map[string]interface{}{"id >": 100}, fields, Keyset{Column: "id", After: 104}, 2, &items)
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal([]*pageItem{{105, "x"}}, items)
+	ass.Equal(&Pagination{PageSize: 2, Total: 5, PageCount: 3, Prev: int64(105)}, p)
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb WHERE (id<$1) ORDER BY id DESC LIMIT 3 OFFSET 0")).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(fields).AddRow(int64(4), "x").AddRow(int64(3), "x").AddRow(int64(2), "x"))
+	p, err = PaginateKeyset(ctx, db, "tb", nil, fields, Keyset{Column: "id", Before: 5}, 2, &items)
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal([]*pageItem{{3, "x"}, {4, "x"}}, items)
+	ass.Equal(&Pagination{PageSize: 2, Total: 5, PageCount: 3, Prev: int64(3), Next: int64(4)}, p)
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	mock.ExpectQuery("SELECT name FROM tb").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("x"))
+	_, err = PaginateKeyset(ctx, db, "tb", nil, []string{"name"}, Keyset{Column: "uid"}, 2, &items)
+	ass.Equal(errKeysetColumnType, err)
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id,name FROM tb ORDER BY id ASC LIMIT 3 OFFSET 0")).
+		WillReturnRows(sqlmock.NewRows(fields).AddRow(int64(1), "a").AddRow(int64(2), "b").AddRow(int64(3), "c"))
+	byName := Keyset{Column: "id", Value: func(item interface{}) interface{} {
+		return item.(*pageItem).Name
+	}}
+	p, err = PaginateKeyset(ctx, db, "tb", nil, fields, byName, 2, &items)
+	ass.NoError(err)
+	ass.NoError(mock.ExpectationsWereMet())
+	ass.Equal(&Pagination{PageSize: 2, Total: 5, PageCount: 3, Next: "b"}, p)
+
+	_, err = PaginateKeyset(ctx, db, "tb", nil, fields, Keyset{}, 2, &items)
+	ass.Equal(errKeysetColumn, err)
+	_, err = PaginateKeyset(ctx, db, "tb", nil, fields, Keyset{Column: "id", After: 1, Before: 3}, 2, &items)
+	ass.Equal(errKeysetDirection, err)
+}
@@ -0,0 +1,243 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SchemaErr is returned when a where-condition or an update map breaks the rules of a Schema
+type SchemaErr struct {
+	Key    string
+	Reason string
+}
+
+func (s SchemaErr) Error() string {
+	return fmt.Sprintf("[builder] %q rejected by schema: %s", s.Key, s.Reason)
+}
+
+// Column describes a column which is allowed to appear in where-conditions or updates
+type Column struct {
+	Name string
+	// Type is the type every value of the column must be assignable to, nil means any type
+	Type reflect.Type
+	// Operators lists the operators allowed on the column, empty means every supported operator
+	Operators []string
+}
+
+// NewColumn returns a Column whose Type is the type of sample
+// ie: NewColumn("age", 0, ">", "<") only accepts int values compared by > or <
+func NewColumn(name string, sample interface{}, operators ...string) Column {
+	return Column{
+		Name:      name,
+		Type:      reflect.TypeOf(sample),
+		Operators: operators,
+	}
+}
+
+func (c Column) allowOperator(op string) bool {
+	if len(c.Operators) == 0 {
+		return true
+	}
+	return isStringInSlice(op, c.Operators)
+}
+
+func (c Column) checkValue(key string, val interface{}) error {
+	if nil == c.Type {
+		return nil
+	}
+	vt := reflect.TypeOf(val)
+	if nil == vt {
+		return SchemaErr{key, fmt.Sprintf("nil is not a valid %s", c.Type)}
+	}
+	if !vt.AssignableTo(c.Type) {
+		return SchemaErr{key, fmt.Sprintf("value of type %s is not assignable to %s", vt, c.Type)}
+	}
+	return nil
+}
+
+// Schema is a whitelist of the columns which may be used in where-conditions,
+// _orderby,_groupby,_having and updates.
+// it's designed for where maps built from user input, such as HTTP request parameters
+type Schema struct {
+	columns map[string]Column
+}
+
+// NewSchema returns a Schema allowing the given columns
+func NewSchema(columns ...Column) *Schema {
+	s := &Schema{
+		columns: make(map[string]Column, len(columns)),
+	}
+	for _, c := range columns {
+		s.columns[c.Name] = c
+	}
+	return s
+}
+
+func (s *Schema) column(key, field string) (Column, error) {
+	c, ok := s.columns[field]
+	if !ok {
+		return c, SchemaErr{key, fmt.Sprintf("column %q is not allowed", field)}
+	}
+	return c, nil
+}
+
+// Validate checks every key of the where map against the schema
+func (s *Schema) Validate(where map[string]interface{}) error {
+	return s.validate(where)
+}
+
+// ValidateOrdered checks every key of the OrderedWhere against the schema
+func (s *Schema) ValidateOrdered(where OrderedWhere) error {
+	return s.validate(where)
+}
+
+func (s *Schema) validate(where interface{}) error {
+	return rangeWhere(where, func(key string, val interface{}) error {
+		switch key {
+		case "_orderby":
+			return s.validateOrderBy(key, val)
+		case "_groupby":
+			return s.validateGroupBy(key, val)
+		case "_having":
+			switch normalizeWhere(val).(type) {
+			case map[string]interface{}, OrderedWhere:
+				return rangeWhere(val, s.validateCondition)
+			}
+			return errHavingValueType
+		case "_limit":
+			return nil
+		}
+		return s.validateCondition(key, val)
+	})
+}
+
+// ValidateUpdate checks every column and value of the update map against the schema
+func (s *Schema) ValidateUpdate(update map[string]interface{}) error {
+	for key, val := range update {
+		c, err := s.column(key, key)
+		if nil != err {
+			return err
+		}
+		if err = c.checkValue(key, val); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateCondition(key string, val interface{}) error {
+	field, operator, err := splitKey(key)
+	if nil != err {
+		return err
+	}
+	if !isStringInSlice(operator, opOrder) {
+		return SchemaErr{key, fmt.Sprintf("operator %q is not supported", operator)}
+	}
+	c, err := s.column(key, field)
+	if nil != err {
+		return err
+	}
+	if !c.allowOperator(operator) {
+		return SchemaErr{key, fmt.Sprintf("operator %q is not allowed on column %q", operator, field)}
+	}
+	if operator != opIn {
+		return c.checkValue(key, val)
+	}
+	vals, ok := convertInterfaceToMap(val)
+	if !ok {
+		return errWhereInType
+	}
+	for _, v := range vals {
+		if err = c.checkValue(key, v); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateOrderBy(key string, val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errSplitOrderBy
+	}
+	orders, err := splitOrderBy(str)
+	if nil != err {
+		return err
+	}
+	for _, o := range orders {
+		if _, err = s.column(key, o.field); nil != err {
+			return err
+		}
+		direction := strings.ToUpper(o.order)
+		if direction != "ASC" && direction != "DESC" {
+			return SchemaErr{key, fmt.Sprintf("direction %q is neither ASC nor DESC", o.order)}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateGroupBy(key string, val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errGroupByValueType
+	}
+	for _, field := range strings.Split(str, ",") {
+		if _, err := s.column(key, strings.TrimSpace(field)); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildSelect is the same as the package level BuildSelect but validates where against the schema first
+func (s *Schema) BuildSelect(table string, where map[string]interface{}, selectField []string) (string, []interface{}, error) {
+	if err := s.validate(where); nil != err {
+		return "", nil, err
+	}
+	return BuildSelect(table, where, selectField)
+}
+
+// BuildSelectOrdered is the same as the package level BuildSelectOrdered but validates where against the schema first
+func (s *Schema) BuildSelectOrdered(table string, where OrderedWhere, selectField []string) (string, []interface{}, error) {
+	if err := s.validate(where); nil != err {
+		return "", nil, err
+	}
+	return BuildSelectOrdered(table, where, selectField)
+}
+
+// BuildUpdate is the same as the package level BuildUpdate but validates where and update against the schema first
+func (s *Schema) BuildUpdate(table string, where map[string]interface{}, update map[string]interface{}) (string, []interface{}, error) {
+	return s.buildUpdate(table, where, update)
+}
+
+// BuildUpdateOrdered is the same as the package level BuildUpdateOrdered but validates where and update against the schema first
+func (s *Schema) BuildUpdateOrdered(table string, where OrderedWhere, update map[string]interface{}) (string, []interface{}, error) {
+	return s.buildUpdate(table, where, update)
+}
+
+func (s *Schema) buildUpdate(table string, where interface{}, update map[string]interface{}) (string, []interface{}, error) {
+	if err := s.validate(where); nil != err {
+		return "", nil, err
+	}
+	if err := s.ValidateUpdate(update); nil != err {
+		return "", nil, err
+	}
+	return updateWhere(table, where, update)
+}
+
+// BuildDelete is the same as the package level BuildDelete but validates where against the schema first
+func (s *Schema) BuildDelete(table string, where map[string]interface{}) (string, []interface{}, error) {
+	if err := s.validate(where); nil != err {
+		return "", nil, err
+	}
+	return BuildDelete(table, where)
+}
+
+// BuildDeleteOrdered is the same as the package level BuildDeleteOrdered but validates where against the schema first
+func (s *Schema) BuildDeleteOrdered(table string, where OrderedWhere) (string, []interface{}, error) {
+	if err := s.validate(where); nil != err {
+		return "", nil, err
+	}
+	return BuildDeleteOrdered(table, where)
+}
@@ -0,0 +1,111 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := NewSchema(
+		NewColumn("name", "", "=", "like"),
+		NewColumn("age", 0, ">", "<", "in"),
+		Column{Name: "total"},
+	)
+	var data = []struct {
+		where map[string]interface{}
+		err   error
+	}{
+		{
+			where: map[string]interface{}{
+				"name":     "deen",
+				"age >":    20,
+				"age in":   []int{21, 22},
+				"_orderby": "age desc, name asc",
+				"_groupby": "name, age",
+				"_having":  map[string]interface{}{"total >": 10},
+				"_limit":   []uint{10, 0},
+			},
+			err: nil,
+		},
+		{
+			where: map[string]interface{}{"password": "123"},
+			err:   SchemaErr{"password", `column "password" is not allowed`},
+		},
+		{
+			where: map[string]interface{}{"age <>": 10},
+			err:   SchemaErr{"age <>", `operator "<>" is not allowed on column "age"`},
+		},
+		{
+			where: map[string]interface{}{"age ~": 10},
+			err:   SchemaErr{"age ~", `operator "~" is not supported`},
+		},
+		{
+			where: map[string]interface{}{"age >": "10"},
+			err:   SchemaErr{"age >", "value of type string is not assignable to int"},
+		},
+		{
+			where: map[string]interface{}{"age in": []interface{}{1, "2"}},
+			err:   SchemaErr{"age in", "value of type string is not assignable to int"},
+		},
+		{
+			where: map[string]interface{}{"name": nil},
+			err:   SchemaErr{"name", "nil is not a valid string"},
+		},
+		{
+			where: map[string]interface{}{"_orderby": "(select 1) desc"},
+			err:   SchemaErr{"_orderby", `column "(select" is not allowed`},
+		},
+		{
+			where: map[string]interface{}{"_orderby": "age; desc"},
+			err:   SchemaErr{"_orderby", `column "age;" is not allowed`},
+		},
+		{
+			where: map[string]interface{}{"_orderby": "age up"},
+			err:   SchemaErr{"_orderby", `direction "up" is neither ASC nor DESC`},
+		},
+		{
+			where: map[string]interface{}{"_groupby": "name,secret"},
+			err:   SchemaErr{"_groupby", `column "secret" is not allowed`},
+		},
+		{
+			where: map[string]interface{}{"_having": map[string]interface{}{"secret": 1}},
+			err:   SchemaErr{"secret", `column "secret" is not allowed`},
+		},
+	}
+	ass := assert.New(t)
+	for idx, tc := range data {
+		ass.Equal(tc.err, schema.Validate(tc.where), "idx:%d", idx)
+	}
+}
+
+func TestSchemaBuild(t *testing.T) {
+	schema := NewSchema(
+		NewColumn("name", ""),
+		NewColumn("age", 0),
+	)
+	ass := assert.New(t)
+
+	cond, vals, err := schema.BuildSelect("tb", map[string]interface{}{"age >": 10}, nil)
+	ass.NoError(err)
+	ass.Equal("SELECT * FROM tb WHERE (age>$1)", cond)
+	ass.Equal([]interface{}{10}, vals)
+	_, _, err = schema.BuildSelect("tb", map[string]interface{}{"id": 10}, nil)
+	ass.Equal(SchemaErr{"id", `column "id" is not allowed`}, err)
+
+	cond, vals, err = schema.BuildUpdate("tb", map[string]interface{}{"name": "deen"}, map[string]interface{}{"age": 23})
+	ass.NoError(err)
+	ass.Equal("UPDATE tb SET age=$1 WHERE (name=$2)", cond)
+	ass.Equal([]interface{}{23, "deen"}, vals)
+	_, _, err = schema.BuildUpdate("tb", map[string]interface{}{"name": "deen"}, map[string]interface{}{"role": "admin"})
+	ass.Equal(SchemaErr{"role", `column "role" is not allowed`}, err)
+	_, _, err = schema.BuildUpdate("tb", map[string]interface{}{"name": "deen"}, map[string]interface{}{"age": "23"})
+	ass.Equal(SchemaErr{"age", "value of type string is not assignable to int"}, err)
+
+	cond, vals, err = schema.BuildDelete("tb", map[string]interface{}{"name": "deen"})
+	ass.NoError(err)
+	ass.Equal("DELETE FROM tb WHERE (name=$1)", cond)
+	ass.Equal([]interface{}{"deen"}, vals)
+	_, _, err = schema.BuildDelete("tb", map[string]interface{}{"1=1 or id": 1})
+	ass.Equal(SchemaErr{"1=1 or id", `operator "or id" is not supported`}, err)
+}
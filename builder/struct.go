@@ -0,0 +1,289 @@
+package builder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/didi/gendry/scanner"
+)
+
+var (
+	errNoneStructTarget = errors.New("[builder] target must be a struct or a pointer to struct")
+	errNoPrimaryKey     = errors.New(`[builder] struct has no field tagged with "pk"`)
+	errUpdateNullData   = errors.New("[builder] nothing to update")
+)
+
+// fieldTag is the parsed form of `ddb:"name,opt1,opt2"`
+type fieldTag struct {
+	name      string
+	op        string
+	omitEmpty bool
+	pk        bool
+	readonly  bool
+	// the column has a default value in database
+	hasDefault bool
+	// the field is stored as json
+	json bool
+}
+
+func parseFieldTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	ft := fieldTag{
+		name: strings.TrimSpace(parts[0]),
+		op:   opEq,
+	}
+	for _, opt := range parts[1:] {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "omitempty":
+			ft.omitEmpty = true
+		case opt == "pk":
+			ft.pk = true
+		case opt == "readonly":
+			ft.readonly = true
+		case opt == "default":
+			ft.hasDefault = true
+		case opt == "json":
+			ft.json = true
+		case strings.HasPrefix(opt, "op="):
+			ft.op = strings.TrimSpace(opt[len("op="):])
+		}
+	}
+	return ft
+}
+
+type structField struct {
+	tag   fieldTag
+	value reflect.Value
+}
+
+func indirectStruct(target interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(target)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, errNoneStructTarget
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return v, errNoneStructTarget
+	}
+	return v, nil
+}
+
+// resolveStructFields returns the fields of v bound to the columns by the default scanner, see scanner.Columns.
+// the column of a field is its prefixed name, and the rest of its tag are the options.
+// a field behind a nil pointer is skipped
+func resolveStructFields(v reflect.Value) []structField {
+	// v is always a struct
+	columns, _ := scanner.Columns(v.Type())
+	fields := make([]structField, 0, len(columns))
+	for _, c := range columns {
+		fv, ok := c.Field(v)
+		if !ok {
+			continue
+		}
+		ft := parseFieldTag(c.Tag)
+		ft.name = c.Name
+		fields = append(fields, structField{ft, fv})
+	}
+	return fields
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// WhereFromStruct builds a where map from the ddb tags of a struct, so that a request DTO
+// can be passed to BuildSelect directly.
+// besides the column name, the tag accepts the options:
+// op=xxx: the operator used to compare the column, it's = by default(ie: `ddb:"age,op=>="`)
+// omitempty: the field is skipped if it holds a zero value(ie: `ddb:"name,op=like,omitempty"`),
+// a pointer is only skipped if it's nil, so a pointer to a zero value is compared.
+// fields tagged with "-", untagged fields and nil pointers are always skipped,
+// anonymous embedded structs are flattened.
+func WhereFromStruct(target interface{}) (map[string]interface{}, error) {
+	v, err := indirectStruct(target)
+	if nil != err {
+		return nil, err
+	}
+	where := make(map[string]interface{})
+	for _, f := range resolveStructFields(v) {
+		fv := f.value
+		// a pointer tells whether the field is set, so omitempty only skips a nil one and a zero value it points to is kept
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if f.tag.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if !isStringInSlice(f.tag.op, opOrder) {
+			return nil, ErrUnsupportedOperator
+		}
+		if f.tag.op == opIn && fv.Kind() != reflect.Slice {
+			return nil, errWhereInType
+		}
+		key := f.tag.name
+		if f.tag.op != opEq {
+			key += " " + f.tag.op
+		}
+		where[key] = fv.Interface()
+	}
+	return where, nil
+}
+
+// fieldValue returns the value of the field, pointers are dereferenced and nil pointers become nil
+func fieldValue(fv reflect.Value) interface{} {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		return fv.Elem().Interface()
+	}
+	return fv.Interface()
+}
+
+// columnValue returns the value f is stored as, a field with the json option is marshaled into a json string,
+// or NULL if it's a nil pointer, interface, map or slice
+func columnValue(f structField) (interface{}, error) {
+	if !f.tag.json {
+		return fieldValue(f.value), nil
+	}
+	switch f.value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if f.value.IsNil() {
+			return nil, nil
+		}
+	}
+	b, err := json.Marshal(f.value.Interface())
+	if nil != err {
+		return nil, fmt.Errorf("[builder] fail to marshal %s into json, err: %w", f.tag.name, err)
+	}
+	return string(b), nil
+}
+
+// BuildInsertStruct builds an insert statement from a struct or a slice of structs.
+// columns come from the ddb tags, and the options of the tag are:
+// readonly: the field is never inserted(ie: a column filled by database)
+// omitempty: the field isn't inserted when it holds a zero value
+// default: the column has a default value in database, so the field isn't inserted when it holds a zero value
+// json: the field is marshaled into a json string
+// for a slice, a column is inserted for every row if any row of the slice needs it,
+// the rows skipping it insert DEFAULT instead.
+// a nil pointer field is inserted as NULL
+func BuildInsertStruct(table string, data interface{}) (string, []interface{}, error) {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() != reflect.Struct {
+		v = v.Elem()
+	}
+	var rows []reflect.Value
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			row, err := indirectStruct(v.Index(i).Interface())
+			if nil != err {
+				return "", nil, err
+			}
+			rows = append(rows, row)
+		}
+	} else {
+		row, err := indirectStruct(data)
+		if nil != err {
+			return "", nil, err
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return "", nil, errInsertNullData
+	}
+	included := make(map[string]bool)
+	setMap := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		setMap[i] = make(map[string]interface{})
+		for _, f := range resolveStructFields(row) {
+			if f.tag.readonly {
+				continue
+			}
+			// the column is left to the database, it's DEFAULT if another row includes it
+			if (f.tag.omitEmpty || f.tag.hasDefault) && isEmptyValue(f.value) {
+				setMap[i][f.tag.name] = sqlDefault{}
+				continue
+			}
+			val, err := columnValue(f)
+			if nil != err {
+				return "", nil, err
+			}
+			setMap[i][f.tag.name] = val
+			included[f.tag.name] = true
+		}
+	}
+	// every column is readonly or left to the database, an empty column list isn't valid SQL
+	if 0 == len(included) {
+		return "", nil, errInsertNullData
+	}
+	for _, mp := range setMap {
+		for k := range mp {
+			if !included[k] {
+				delete(mp, k)
+			}
+		}
+	}
+	return buildInsert(table, setMap)
+}
+
+// BuildUpdateStruct builds an update statement from a struct.
+// fields tagged with pk make up the where-condition, the rest of fields make up the SET part.
+// readonly fields are never updated and omitempty fields aren't updated when they hold a zero value,
+// json fields are marshaled the same as BuildInsertStruct
+func BuildUpdateStruct(table string, data interface{}) (string, []interface{}, error) {
+	v, err := indirectStruct(data)
+	if nil != err {
+		return "", nil, err
+	}
+	where := make(map[string]interface{})
+	update := make(map[string]interface{})
+	for _, f := range resolveStructFields(v) {
+		if !f.tag.pk && (f.tag.readonly || f.tag.omitEmpty && isEmptyValue(f.value)) {
+			continue
+		}
+		val, err := columnValue(f)
+		if nil != err {
+			return "", nil, err
+		}
+		if f.tag.pk {
+			where[f.tag.name] = val
+		} else {
+			update[f.tag.name] = val
+		}
+	}
+	if len(where) == 0 {
+		return "", nil, errNoPrimaryKey
+	}
+	if len(update) == 0 {
+		return "", nil, errUpdateNullData
+	}
+	return BuildUpdate(table, where, update)
+}
+
+// structColumns returns the columns the default scanner binds the fields of struct type t to
+func structColumns(t reflect.Type) ([]string, error) {
+	columns, err := scanner.Columns(t)
+	if nil != err {
+		return nil, errNoneStructTarget
+	}
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names, nil
+}
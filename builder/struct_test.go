@@ -0,0 +1,265 @@
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type author struct {
+	ID   int64  `ddb:"id"`
+	Name string `ddb:"name"`
+}
+
+type post struct {
+	Title   string    `ddb:"title"`
+	Author  author    `ddb:"author,prefix=author_"`
+	Editor  *author   `ddb:"editor,prefix=editor_"`
+	Created time.Time `ddb:"created,prefix=created_"`
+}
+
+type pagination struct {
+	OrderBy string `ddb:"_orderby,omitempty"`
+}
+
+type userFilter struct {
+	pagination
+	Name    string   `ddb:"name,op=like,omitempty"`
+	MinAge  int      `ddb:"age,op=>="`
+	MaxAge  *int     `ddb:"age,op=<"`
+	Cities  []string `ddb:"city,op=in,omitempty"`
+	Role    string   `ddb:"role"`
+	Ignored string   `ddb:"-"`
+	NoTag   string
+	private string `ddb:"private"`
+}
+
+func TestWhereFromStruct(t *testing.T) {
+	maxAge := 60
+	var data = []struct {
+		in    interface{}
+		where map[string]interface{}
+		err   error
+	}{
+		{
+			in: userFilter{
+				pagination: pagination{"age desc"},
+				Name:       "%deen%",
+				MinAge:     18,
+				MaxAge:     &maxAge,
+				Cities:     []string{"Beijing", "Chengdu"},
+				Ignored:    "foo",
+				NoTag:      "bar",
+				private:    "baz",
+			},
+			where: map[string]interface{}{
+				"_orderby":  "age desc",
+				"name like": "%deen%",
+				"age >=":    18,
+				"age <":     60,
+				"city in":   []string{"Beijing", "Chengdu"},
+				"role":      "",
+			},
+		},
+		{
+			in: &userFilter{Role: "driver"},
+			where: map[string]interface{}{
+				"age >=": 0,
+				"role":   "driver",
+			},
+		},
+		{
+			// a pointer to zero is set explicitly
+			in: struct {
+				Age  *int    `ddb:"age,omitempty"`
+				Name *string `ddb:"name,omitempty"`
+			}{Age: new(int)},
+			where: map[string]interface{}{"age": 0},
+		},
+		{
+			in: &struct {
+				Age int `ddb:"age,op=~"`
+			}{},
+			err: ErrUnsupportedOperator,
+		},
+		{
+			in: struct {
+				Age int `ddb:"age,op=in"`
+			}{},
+			err: errWhereInType,
+		},
+		{
+			in:  10,
+			err: errNoneStructTarget,
+		},
+		{
+			in:  (*userFilter)(nil),
+			err: errNoneStructTarget,
+		},
+	}
+	ass := assert.New(t)
+	for idx, tc := range data {
+		where, err := WhereFromStruct(tc.in)
+		ass.Equal(tc.err, err, "idx:%d", idx)
+		ass.Equal(tc.where, where, "idx:%d", idx)
+	}
+}
+
+func TestWhereFromStruct_BuildSelect(t *testing.T) {
+	ass := assert.New(t)
+	where, err := WhereFromStruct(userFilter{MinAge: 18, Cities: []string{"Beijing"}, Role: "driver"})
+	ass.NoError(err)
+	cond, vals, err := BuildSelect("tb", where, nil)
+	ass.NoError(err)
+	ass.Equal("SELECT * FROM tb WHERE (role=$1 AND city IN ($2) AND age>=$3)", cond)
+	ass.Equal([]interface{}{"driver", "Beijing", 18}, vals)
+}
+
+type baseModel struct {
+	ID        int64  `ddb:"id,pk,omitempty"`
+	CreatedAt string `ddb:"created_at,readonly"`
+}
+
+type account struct {
+	baseModel
+	Name   string  `ddb:"name"`
+	Role   string  `ddb:"role,default"`
+	Nick   *string `ddb:"nick"`
+	Remark string  `ddb:"remark,omitempty"`
+	Tmp    string  `ddb:"-"`
+}
+
+func TestBuildInsertStruct(t *testing.T) {
+	nick := "dd"
+	var data = []struct {
+		in   interface{}
+		cond string
+		vals []interface{}
+		err  error
+	}{
+		{
+			in:   account{baseModel: baseModel{CreatedAt: "now"}, Name: "deen", Nick: &nick, Tmp: "x"},
+			cond: "INSERT INTO tb (name,nick) VALUES ($1,$2)",
+			vals: []interface{}{"deen", "dd"},
+		},
+		{
+			in:   &account{baseModel: baseModel{ID: 3}, Name: "deen", Role: "admin", Remark: "hi"},
+			cond: "INSERT INTO tb (id,name,nick,remark,role) VALUES ($1,$2,$3,$4,$5)",
+			vals: []interface{}{int64(3), "deen", nil, "hi", "admin"},
+		},
+		{
+			in:   []account{{Name: "deen"}, {Name: "tony", Role: "driver"}},
+			cond: "INSERT INTO tb (name,nick,role) VALUES ($1,$2,DEFAULT),($3,$4,$5)",
+			vals: []interface{}{"deen", nil, "tony", nil, "driver"},
+		},
+		{
+			// the zero default and omitempty cells are left to the database
+			in: []account{
+				{Name: "deen", Role: "admin"},
+				{baseModel: baseModel{ID: 2}, Name: "tony", Remark: "hi"},
+				{Name: "dd"},
+			},
+			cond: "INSERT INTO tb (id,name,nick,remark,role) VALUES (DEFAULT,$1,$2,DEFAULT,$3),($4,$5,$6,$7,DEFAULT),(DEFAULT,$8,$9,DEFAULT,DEFAULT)",
+			vals: []interface{}{"deen", nil, "admin", int64(2), "tony", nil, "hi", "dd", nil},
+		},
+		{
+			in:   []*account{{Name: "deen"}},
+			cond: "INSERT INTO tb (name,nick) VALUES ($1,$2)",
+			vals: []interface{}{"deen", nil},
+		},
+		{
+			in: struct {
+				Name  string            `ddb:"name"`
+				Attrs map[string]string `ddb:"attrs,json"`
+				Tags  []string          `ddb:"tags,json"`
+			}{"deen", map[string]string{"k": "v"}, nil},
+			cond: "INSERT INTO tb (attrs,name,tags) VALUES ($1,$2,$3)",
+			vals: []interface{}{`{"k":"v"}`, "deen", nil},
+		},
+		{
+			// the fields of a prefixed struct field are flattened, a nil one is skipped
+			in:   post{Title: "hi", Author: author{ID: 2, Name: "deen"}, Created: time.Unix(0, 0)},
+			cond: "INSERT INTO tb (author_id,author_name,created,title) VALUES ($1,$2,$3,$4)",
+			vals: []interface{}{int64(2), "deen", time.Unix(0, 0), "hi"},
+		},
+		{
+			in:   post{Title: "hi", Editor: &author{ID: 3}},
+			cond: "INSERT INTO tb (author_id,author_name,created,editor_id,editor_name,title) VALUES ($1,$2,$3,$4,$5,$6)",
+			vals: []interface{}{int64(0), "", time.Time{}, int64(3), "", "hi"},
+		},
+		{
+			in:  []account{},
+			err: errInsertNullData,
+		},
+		{
+			in:  []baseModel{{CreatedAt: "now"}, {}},
+			err: errInsertNullData,
+		},
+		{
+			in: struct {
+				Role   string `ddb:"role,default"`
+				Remark string `ddb:"remark,omitempty"`
+			}{},
+			err: errInsertNullData,
+		},
+		{
+			in:  []int{1},
+			err: errNoneStructTarget,
+		},
+	}
+	ass := assert.New(t)
+	for idx, tc := range data {
+		cond, vals, err := BuildInsertStruct("tb", tc.in)
+		ass.Equal(tc.err, err, "idx:%d", idx)
+		ass.Equal(tc.cond, cond, "idx:%d", idx)
+		ass.Equal(tc.vals, vals, "idx:%d", idx)
+	}
+	_, _, err := BuildInsertStruct("tb", struct {
+		Ch chan int `ddb:"ch,json"`
+	}{make(chan int)})
+	ass.EqualError(err, "[builder] fail to marshal ch into json, err: json: unsupported type: chan int")
+}
+
+func TestBuildUpdateStruct(t *testing.T) {
+	var data = []struct {
+		in   interface{}
+		cond string
+		vals []interface{}
+		err  error
+	}{
+		{
+			in:   account{baseModel: baseModel{ID: 3, CreatedAt: "now"}, Name: "deen"},
+			cond: "UPDATE tb SET name=$1,nick=$2,role=$3 WHERE (id=$4)",
+			vals: []interface{}{"deen", nil, "", int64(3)},
+		},
+		{
+			in: &struct {
+				ID   int    `ddb:"id,pk"`
+				Name string `ddb:"name,omitempty"`
+			}{ID: 1},
+			err: errUpdateNullData,
+		},
+		{
+			in: struct {
+				Name string `ddb:"name"`
+			}{"deen"},
+			err: errNoPrimaryKey,
+		},
+		{
+			in: struct {
+				ID    int      `ddb:"id,pk"`
+				Attrs struct{} `ddb:"attrs,json"`
+			}{ID: 1},
+			cond: "UPDATE tb SET attrs=$1 WHERE (id=$2)",
+			vals: []interface{}{"{}", 1},
+		},
+	}
+	ass := assert.New(t)
+	for idx, tc := range data {
+		cond, vals, err := BuildUpdateStruct("tb", tc.in)
+		ass.Equal(tc.err, err, "idx:%d", idx)
+		ass.Equal(tc.cond, cond, "idx:%d", idx)
+		ass.Equal(tc.vals, vals, "idx:%d", idx)
+	}
+}
@@ -2,71 +2,230 @@ package builder
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/didi/gendry/scanner"
 )
 
 // AggregateQuery is a helper function to execute the aggregate query and return the result
-func AggregateQuery(ctx context.Context, db *sql.DB, table string, where map[string]interface{}, aggregate AggregateSymbleBuilder) (ResultResolver, error) {
-	cond, vals, err := BuildSelect(table, where, []string{aggregate.Symble()})
+func AggregateQuery(ctx context.Context, db Executor, table string, where map[string]interface{}, aggregate AggregateSymbleBuilder) (ResultResolver, error) {
+	cond, vals, err := BuildAggregate(table, where, nil, aggregate)
 	if nil != err {
-		return resultResolve{0}, err
+		return &resultResolve{}, err
 	}
 	rows, err := db.QueryContext(ctx, cond, vals...)
 	if nil != err {
-		return resultResolve{0}, err
+		return &resultResolve{}, err
 	}
+	defer rows.Close()
 	var result interface{}
 	for rows.Next() {
-		err = rows.Scan(&result)
+		if err = rows.Scan(&result); nil != err {
+			return &resultResolve{}, err
+		}
 	}
-	rows.Close()
-	return resultResolve{result}, err
+	return &resultResolve{data: result}, rows.Err()
 }
 
 // ResultResolver is a helper for retrieving data
-// caller should know the type and call the responding method
+// caller should know the type and call the responding method.
+// numerics returned as text, such as numeric of postgres and decimal of mysql, are parsed.
+// a NULL result(for example sum() over an empty table) is reported by IsNull,
+// every other method returns the zero value for it.
+// Int64, Float64, Time and Decimal return the zero value if the result can't be converted, Err reports why
 type ResultResolver interface {
 	Int64() int64
 	Float64() float64
+	String() string
+	Time() time.Time
+	// Decimal returns the exact value, it's nil if the result is NULL
+	Decimal() *big.Rat
+	IsNull() bool
+	// Err returns the error of the last conversion, nil if it succeeded
+	Err() error
 }
 
+var errIntOverflow = errors.New("[builder] result overflows int64")
+
 type resultResolve struct {
 	data interface{}
+	// mu guards err, which is set by every conversion
+	mu  sync.Mutex
+	err error
+}
+
+func (r *resultResolve) setErr(err error) {
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+}
+
+func (r *resultResolve) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *resultResolve) convertErr(to string) error {
+	return fmt.Errorf("[builder] can't convert result of %T to %s", r.data, to)
+}
+
+// text returns the result as string if it's returned as text by the driver
+func (r *resultResolve) text() (string, bool) {
+	switch d := r.data.(type) {
+	case []byte:
+		return string(d), true
+	case string:
+		return d, true
+	}
+	return "", false
 }
 
-func (r resultResolve) Int64() int64 {
-	i64, ok := r.data.(int64)
-	if ok {
-		return i64
+func (r *resultResolve) Int64() int64 {
+	i64, err := r.toInt64()
+	r.setErr(err)
+	return i64
+}
+
+func (r *resultResolve) toInt64() (int64, error) {
+	switch d := r.data.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return d, nil
+	case int32:
+		return int64(d), nil
+	case int:
+		return int64(d), nil
+	case float64:
+		return int64(d), nil
+	case float32:
+		return int64(d), nil
+	}
+	s, ok := r.text()
+	if !ok {
+		return 0, r.convertErr("int64")
 	}
-	i32, ok := r.data.(int32)
-	if ok {
-		return int64(i32)
+	if i64, err := strconv.ParseInt(s, 10, 64); nil == err {
+		return i64, nil
 	}
-	i, ok := r.data.(int)
-	if ok {
-		return int64(i)
+	rat, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, r.convertErr("int64")
 	}
-	f64, ok := r.data.(float64)
-	if ok {
-		return int64(f64)
+	// truncate toward zero, the same as converting a float
+	i := new(big.Int).Quo(rat.Num(), rat.Denom())
+	if !i.IsInt64() {
+		return 0, errIntOverflow
 	}
-	f32, _ := r.data.(float32)
-	return int64(f32)
+	return i.Int64(), nil
 }
 
 // from go-mysql-driver/mysql the value returned could be int64 float64 float32
 
-func (r resultResolve) Float64() float64 {
-	f64, ok := r.data.(float64)
-	if ok {
-		return f64
+func (r *resultResolve) Float64() float64 {
+	f64, err := r.toFloat64()
+	r.setErr(err)
+	return f64
+}
+
+func (r *resultResolve) toFloat64() (float64, error) {
+	switch d := r.data.(type) {
+	case float64:
+		return d, nil
+	case float32:
+		return float64(d), nil
+	case int64, int32, int, nil:
+		i64, err := r.toInt64()
+		return float64(i64), err
+	}
+	s, ok := r.text()
+	if !ok {
+		return 0, r.convertErr("float64")
+	}
+	f64, err := strconv.ParseFloat(s, 64)
+	if nil != err {
+		return 0, r.convertErr("float64")
+	}
+	return f64, nil
+}
+
+func (r *resultResolve) String() string {
+	if s, ok := r.text(); ok {
+		return s
+	}
+	switch d := r.data.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return d.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(r.data)
+}
+
+func (r *resultResolve) Time() time.Time {
+	t, err := r.toTime()
+	r.setErr(err)
+	return t
+}
+
+func (r *resultResolve) toTime() (time.Time, error) {
+	switch d := r.data.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return d, nil
 	}
-	f32, ok := r.data.(float32)
-	if ok {
-		return float64(f32)
+	if s, ok := r.text(); ok {
+		for _, layout := range scanner.DefaultTimeLayouts {
+			if t, err := time.Parse(layout, s); nil == err {
+				return t, nil
+			}
+		}
 	}
-	return float64(r.Int64())
+	return time.Time{}, r.convertErr("time.Time")
+}
+
+func (r *resultResolve) Decimal() *big.Rat {
+	rat, err := r.toDecimal()
+	r.setErr(err)
+	return rat
+}
+
+func (r *resultResolve) toDecimal() (*big.Rat, error) {
+	switch d := r.data.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return new(big.Rat).SetInt64(d), nil
+	case int32:
+		return new(big.Rat).SetInt64(int64(d)), nil
+	case int:
+		return new(big.Rat).SetInt64(int64(d)), nil
+	case float64:
+		if rat := new(big.Rat).SetFloat64(d); nil != rat {
+			return rat, nil
+		}
+	case float32:
+		if rat := new(big.Rat).SetFloat64(float64(d)); nil != rat {
+			return rat, nil
+		}
+	}
+	if s, ok := r.text(); ok {
+		if rat, ok := new(big.Rat).SetString(s); ok {
+			return rat, nil
+		}
+	}
+	return nil, r.convertErr("*big.Rat")
+}
+
+func (r *resultResolve) IsNull() bool {
+	return nil == r.data
 }
 
 // AggregateSymbleBuilder need to be implemented so that executor can
@@ -3,7 +3,9 @@ package builder
 import (
 	"context"
 	"math"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
@@ -25,15 +27,79 @@ func TestResultResolver(t *testing.T) {
 			intout:   4,
 			floatout: 4.5,
 		},
+		{
+			origin:   []byte("1234"),
+			intout:   1234,
+			floatout: 1234.0,
+		},
+		{
+			origin:   []byte("-12.75"),
+			intout:   -12,
+			floatout: -12.75,
+		},
+		{
+			origin:   "99.5",
+			intout:   99,
+			floatout: 99.5,
+		},
+		{
+			origin:   nil,
+			intout:   0,
+			floatout: 0,
+		},
 	}
 	ass := assert.New(t)
 	for _, tc := range testData {
-		rr := resultResolve{tc.origin}
+		rr := &resultResolve{data: tc.origin}
 		ass.Equal(tc.intout, rr.Int64())
+		ass.NoError(rr.Err())
 		ass.True(math.Abs(tc.floatout-rr.Float64()) < 1e-5)
+		ass.NoError(rr.Err())
 	}
 }
 
+func TestResultResolver_Accessors(t *testing.T) {
+	ass := assert.New(t)
+	rr := &resultResolve{data: []byte("123456789012345678901234.5")}
+	ass.Equal("123456789012345678901234.5", rr.String())
+	ass.Equal("246913578024691357802469/2", rr.Decimal().String())
+	ass.NoError(rr.Err())
+	ass.Equal(int64(0), rr.Int64())
+	ass.Equal(errIntOverflow, rr.Err())
+	ass.False(rr.IsNull())
+
+	rr = &resultResolve{data: []byte("abc")}
+	ass.Equal(int64(0), rr.Int64())
+	ass.EqualError(rr.Err(), "[builder] can't convert result of []uint8 to int64")
+	ass.Nil(rr.Decimal())
+	ass.Error(rr.Err())
+	ass.Equal(float64(0), rr.Float64())
+	ass.Error(rr.Err())
+	ass.Equal("abc", rr.String())
+
+	rr = &resultResolve{data: []byte("2018-01-02 15:04:05.123+08")}
+	ass.True(time.Date(2018, 1, 2, 7, 4, 5, 123000000, time.UTC).Equal(rr.Time()))
+	ass.NoError(rr.Err())
+	now := time.Now()
+	rr = &resultResolve{data: now}
+	ass.Equal(now, rr.Time())
+	ass.Equal(now.Format(time.RFC3339Nano), rr.String())
+	rr = &resultResolve{data: 1.5}
+	ass.Equal(big.NewRat(3, 2), rr.Decimal())
+	ass.True(rr.Time().IsZero())
+	ass.Error(rr.Err())
+	// a later conversion succeeding clears the error
+	ass.Equal(1.5, rr.Float64())
+	ass.NoError(rr.Err())
+
+	rr = &resultResolve{}
+	ass.True(rr.IsNull())
+	ass.Nil(rr.Decimal())
+	ass.Equal("", rr.String())
+	ass.True(rr.Time().IsZero())
+	ass.NoError(rr.Err())
+}
+
 func TestAggregateQuery(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if nil != err {
@@ -106,4 +172,18 @@ func TestAggregateQuery(t *testing.T) {
 		ass.Equal(tc.intout, result.Int64())
 		ass.True(math.Abs(result.Float64()-tc.floatout) < 1e6)
 	}
+
+	mock.ExpectQuery("sum\\(age\\)").WillReturnRows(sqlmock.NewRows([]string{"sum(age)"}).AddRow([]byte("1234.50")))
+	result, err := AggregateQuery(ctx, db, "tb1", nil, AggregateSum("age"))
+	ass.NoError(err)
+	ass.Equal(int64(1234), result.Int64())
+	ass.Equal(1234.5, result.Float64())
+	ass.Equal("1234.50", result.String())
+
+	mock.ExpectQuery("sum\\(age\\)").WillReturnRows(sqlmock.NewRows([]string{"sum(age)"}).AddRow(nil))
+	result, err = AggregateQuery(ctx, db, "tb1", nil, AggregateSum("age"))
+	ass.NoError(err)
+	ass.True(result.IsNull())
+	ass.Equal(int64(0), result.Int64())
+	ass.NoError(mock.ExpectationsWereMet())
 }
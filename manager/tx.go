@@ -0,0 +1,186 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/didi/gendry/builder"
+)
+
+var (
+	// ErrTxTarget means WithTx is given something it can't begin a transaction on
+	ErrTxTarget = errors.New("[manager] db must be a *sql.DB, a *sql.Conn, a *sql.Tx or the transaction passed by WithTx")
+	// ErrNilTxTarget means WithTx is given a nil *sql.DB, *sql.Conn or *sql.Tx
+	ErrNilTxTarget = errors.New("[manager] db of WithTx is a nil pointer")
+)
+
+// TxOptions controls how WithTx begins and retries a transaction
+type TxOptions struct {
+	// Isolation and ReadOnly are passed to BeginTx
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// MaxRetries is the number of times a transaction failed by a serialization failure(40001)
+	// or a deadlock(40P01) is retried, 0 disables retrying
+	MaxRetries int
+	// Backoff is the wait before the first retry, it doubles for every retry after
+	Backoff time.Duration
+}
+
+// DefaultTxOptions is used by WithTx when opts is nil
+var DefaultTxOptions = TxOptions{
+	MaxRetries: 3,
+	Backoff:    10 * time.Millisecond,
+}
+
+// retryableSQLStates are the SQLSTATEs after which the whole transaction can be run again
+var retryableSQLStates = []string{"40001", "40P01"}
+
+// savepointSeq numbers the savepoints, the names are unique in the process
+// so the nested calls on the same bare *sql.Tx don't reuse a name
+var savepointSeq uint64
+
+// Tx is the transaction WithTx passes to its fn, passing it to WithTx again makes a savepoint
+type Tx struct {
+	tx *sql.Tx
+}
+
+// QueryContext implements builder.Executor
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements builder.Executor
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+// QueryRowContext implements builder.Executor
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+// Unwrap returns the underlying *sql.Tx
+func (t *Tx) Unwrap() *sql.Tx {
+	return t.tx
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// WithTx runs fn in a transaction, which is committed if fn returns nil and rolled back otherwise or if fn panics.
+// if db is a transaction, fn runs in a SAVEPOINT instead, which is released or rolled back to the same way,
+// opts is ignored then. a transaction failed by a serialization failure or a deadlock is retried as a whole,
+// so fn must be safe to run more than once
+func WithTx(ctx context.Context, db builder.Executor, opts *TxOptions, fn func(tx builder.Executor) error) error {
+	if isNil(db) {
+		return ErrNilTxTarget
+	}
+	switch d := db.(type) {
+	case *Tx:
+		return withSavepoint(ctx, d, fn)
+	case *sql.Tx:
+		return withSavepoint(ctx, &Tx{tx: d}, fn)
+	case txBeginner:
+		if nil == opts {
+			opts = &DefaultTxOptions
+		}
+		return withRetry(ctx, opts, func() error {
+			return withTx(ctx, d, opts, fn)
+		})
+	}
+	return ErrTxTarget
+}
+
+// isNil reports whether db is a nil pointer, ie: a *sql.DB which isn't opened
+func isNil(db builder.Executor) bool {
+	if t, ok := db.(*Tx); ok {
+		return nil == t || nil == t.tx
+	}
+	v := reflect.ValueOf(db)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+func withTx(ctx context.Context, db txBeginner, opts *TxOptions, fn func(tx builder.Executor) error) (err error) {
+	sqlTx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if nil != err {
+		return err
+	}
+	defer func() {
+		if p := recover(); nil != p {
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(&Tx{tx: sqlTx}); nil != err {
+		if rbErr := sqlTx.Rollback(); nil != rbErr {
+			return errors.Join(err, fmt.Errorf("[manager] fail to roll back the transaction, err: %w", rbErr))
+		}
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+func withSavepoint(ctx context.Context, tx *Tx, fn func(tx builder.Executor) error) (err error) {
+	name := "sp_" + strconv.FormatUint(atomic.AddUint64(&savepointSeq, 1), 10)
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT "+name); nil != err {
+		return err
+	}
+	defer func() {
+		if p := recover(); nil != p {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+	if err = fn(tx); nil != err {
+		// the transaction is in an unknown state if the partial rollback fails, the caller must know
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); nil != rbErr {
+			return errors.Join(err, fmt.Errorf("[manager] fail to roll back to savepoint %s, err: %w", name, rbErr))
+		}
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+func withRetry(ctx context.Context, opts *TxOptions, run func() error) error {
+	backoff := opts.Backoff
+	for retries := 0; ; retries++ {
+		err := run()
+		if nil == err || retries >= opts.MaxRetries || !isRetryable(err) {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// isRetryable reports whether err carries one of the retryableSQLStates,
+// drivers such as pgx and lib/pq expose it by a SQLState method
+func isRetryable(err error) bool {
+	var stateErr interface {
+		SQLState() string
+	}
+	if !errors.As(err, &stateErr) {
+		return false
+	}
+	state := stateErr.SQLState()
+	for _, s := range retryableSQLStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
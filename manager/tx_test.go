@@ -0,0 +1,216 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/didi/gendry/builder"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type stateErr string
+
+func (s stateErr) Error() string {
+	return "pq: " + string(s)
+}
+
+func (s stateErr) SQLState() string {
+	return string(s)
+}
+
+func TestWithTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE tb").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err = WithTx(ctx, db, nil, func(tx builder.Executor) error {
+		_, err := tx.ExecContext(ctx, "UPDATE tb SET a=1")
+		return err
+	})
+	ass.NoError(err)
+
+	fnErr := errors.New("insufficient balance")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = WithTx(ctx, db, nil, func(tx builder.Executor) error {
+		return fnErr
+	})
+	ass.Equal(fnErr, err)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	ass.Panics(func() {
+		WithTx(ctx, db, nil, func(tx builder.Executor) error {
+			panic("boom")
+		})
+	})
+
+	ass.Equal(ErrTxTarget, WithTx(ctx, nil, nil, func(tx builder.Executor) error { return nil }))
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+// expectSavepoints returns the names of the next n savepoints
+func expectSavepoints(n int) []string {
+	next := atomic.LoadUint64(&savepointSeq)
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("sp_%d", next+uint64(i)+1)
+	}
+	return names
+}
+
+func TestWithTx_Savepoint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+
+	fnErr := errors.New("skip")
+	sp := expectSavepoints(3)
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT " + sp[0] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO a").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT " + sp[0] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT " + sp[1] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT " + sp[2] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT " + sp[2] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT " + sp[1] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	err = WithTx(ctx, db, nil, func(tx builder.Executor) error {
+		ass.NoError(WithTx(ctx, tx, nil, func(tx builder.Executor) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO a VALUES (1)")
+			return err
+		}))
+		ass.Equal(fnErr, WithTx(ctx, tx, nil, func(tx builder.Executor) error {
+			return WithTx(ctx, tx, nil, func(tx builder.Executor) error {
+				return fnErr
+			})
+		}))
+		return nil
+	})
+	ass.NoError(err)
+
+	// a bare *sql.Tx nested again doesn't reuse the name of the outer savepoint
+	sp = expectSavepoints(2)
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT " + sp[0] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT " + sp[1] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT " + sp[1] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT " + sp[0] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	sqlTx, err := db.Begin()
+	ass.NoError(err)
+	ass.NoError(WithTx(ctx, sqlTx, nil, func(tx builder.Executor) error {
+		ass.IsType(&Tx{}, tx)
+		ass.IsType(&sql.Tx{}, tx.(*Tx).Unwrap())
+		return WithTx(ctx, sqlTx, nil, func(builder.Executor) error {
+			return nil
+		})
+	}))
+	ass.NoError(sqlTx.Rollback())
+
+	// a failed partial rollback is reported along with the error of fn
+	rbErr := errors.New("conn lost")
+	sp = expectSavepoints(1)
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT " + sp[0] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT " + sp[0] + "$").WillReturnError(rbErr)
+	mock.ExpectRollback()
+	sqlTx, err = db.Begin()
+	ass.NoError(err)
+	err = WithTx(ctx, sqlTx, nil, func(builder.Executor) error {
+		return fnErr
+	})
+	ass.ErrorIs(err, fnErr)
+	ass.ErrorIs(err, rbErr)
+	ass.NoError(sqlTx.Rollback())
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+func TestWithTx_Nil(t *testing.T) {
+	ass := assert.New(t)
+	fn := func(builder.Executor) error {
+		return nil
+	}
+	ctx := context.Background()
+	var db *sql.DB
+	ass.Equal(ErrNilTxTarget, WithTx(ctx, db, nil, fn))
+	var tx *sql.Tx
+	ass.Equal(ErrNilTxTarget, WithTx(ctx, tx, nil, fn))
+	ass.Equal(ErrNilTxTarget, WithTx(ctx, &Tx{}, nil, fn))
+}
+
+func TestWithTx_Retry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+	opts := &TxOptions{Isolation: sql.LevelSerializable, MaxRetries: 2}
+
+	var runs int
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	err = WithTx(ctx, db, opts, func(tx builder.Executor) error {
+		runs++
+		if runs == 1 {
+			return stateErr("40001")
+		}
+		if runs == 2 {
+			return fmt.Errorf("update: %w", stateErr("40P01"))
+		}
+		return nil
+	})
+	ass.NoError(err)
+	ass.Equal(3, runs)
+
+	// a serialization failure of the commit is retried as well
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(stateErr("40001"))
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	ass.NoError(WithTx(ctx, db, opts, func(tx builder.Executor) error { return nil }))
+
+	// gives up after MaxRetries
+	runs = 0
+	for i := 0; i < 3; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+	err = WithTx(ctx, db, opts, func(tx builder.Executor) error {
+		runs++
+		return stateErr("40001")
+	})
+	ass.Equal(stateErr("40001"), err)
+	ass.Equal(3, runs)
+
+	// other errors aren't retried
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = WithTx(ctx, db, opts, func(tx builder.Executor) error {
+		return stateErr("23505")
+	})
+	ass.Equal(stateErr("23505"), err)
+	ass.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+)
+
+// arrayElem is an element of a postgres array literal, either NULL, a text or a nested array
+type arrayElem struct {
+	null   bool
+	text   string
+	nested bool
+	elems  []arrayElem
+}
+
+// arrayParser parses the text form of a postgres array, ie: {1,2,NULL}, {"a b","c\"d"}, {{1,2},{3,4}}
+type arrayParser struct {
+	s   string
+	pos int
+}
+
+// parseArray parses the array literal s, the optional dimension decoration like [0:2]= is skipped
+func parseArray(s string) ([]arrayElem, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return nil, false
+		}
+		s = strings.TrimSpace(s[eq+1:])
+	}
+	p := &arrayParser{s: s}
+	elems, ok := p.parse()
+	if !ok || p.pos != len(p.s) {
+		return nil, false
+	}
+	return elems, true
+}
+
+func (p *arrayParser) skipSpaces() {
+	for p.pos < len(p.s) && (' ' == p.s[p.pos] || '\t' == p.s[p.pos] || '\n' == p.s[p.pos] || '\r' == p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *arrayParser) parse() ([]arrayElem, bool) {
+	if p.pos >= len(p.s) || '{' != p.s[p.pos] {
+		return nil, false
+	}
+	p.pos++
+	p.skipSpaces()
+	elems := []arrayElem{}
+	if p.pos < len(p.s) && '}' == p.s[p.pos] {
+		p.pos++
+		return elems, true
+	}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.s) {
+			return nil, false
+		}
+		var elem arrayElem
+		var ok bool
+		switch p.s[p.pos] {
+		case '{':
+			elem.nested = true
+			elem.elems, ok = p.parse()
+		case '"':
+			elem.text, ok = p.quoted()
+		default:
+			elem, ok = p.unquoted()
+		}
+		if !ok {
+			return nil, false
+		}
+		elems = append(elems, elem)
+		p.skipSpaces()
+		if p.pos >= len(p.s) {
+			return nil, false
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return elems, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// quoted reads a double quoted element, a backslash escapes the next character
+func (p *arrayParser) quoted() (string, bool) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), true
+		case '\\':
+			if p.pos >= len(p.s) {
+				return "", false
+			}
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// unquoted reads an element up to the next delimiter, an unquoted NULL is the NULL element
+func (p *arrayParser) unquoted() (arrayElem, bool) {
+	var b strings.Builder
+	var escaped bool
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if ',' == c || '}' == c {
+			break
+		}
+		if '{' == c || '"' == c {
+			return arrayElem{}, false
+		}
+		p.pos++
+		if '\\' == c {
+			if p.pos >= len(p.s) {
+				return arrayElem{}, false
+			}
+			c = p.s[p.pos]
+			p.pos++
+			escaped = true
+		}
+		b.WriteByte(c)
+	}
+	text := strings.TrimRight(b.String(), " \t\n\r")
+	if "" == text {
+		return arrayElem{}, false
+	}
+	if !escaped && strings.EqualFold("NULL", text) {
+		return arrayElem{null: true}, true
+	}
+	return arrayElem{text: text}, true
+}
+
+// isArrayTarget reports whether a field of type t takes a postgres array, which is any slice but []byte
+func isArrayTarget(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && !t.Implements(byteUnmarshalerType)
+}
+
+// handleConvertArray binds the text form of a postgres array to a slice,
+// it reports false if the field isn't a slice other than []byte
+func (s *Scanner) handleConvertArray(text string, mvt reflect.Type, tc *typeConv, valuei *reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) (bool, error) {
+	if !tc.array {
+		return false, nil
+	}
+	elems, ok := parseArray(text)
+	if !ok {
+		return true, wrapErr(mvt, tc.typ)
+	}
+	return true, s.assignArray(elems, mvt, tc, *valuei, wrapErr)
+}
+
+// assignArray converts the elements into a new slice of the type of tc set to v.
+// a NULL element follows the same rule as a NULL column, it sets nil to a pointer and leaves others zero
+func (s *Scanner) assignArray(elems []arrayElem, mvt reflect.Type, tc *typeConv, v reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) error {
+	slice := reflect.MakeSlice(tc.typ, len(elems), len(elems))
+	for i, elem := range elems {
+		var err error
+		switch {
+		case elem.nested && tc.elem.array:
+			err = s.assignArray(elem.elems, mvt, tc.elem, slice.Index(i), wrapErr)
+		case elem.nested:
+			err = wrapErr(mvt, tc.typ)
+		case elem.null:
+			err = s.convert(nil, slice.Index(i), tc.elem, wrapErr)
+		default:
+			err = s.convert([]byte(elem.text), slice.Index(i), tc.elem, wrapErr)
+		}
+		if nil != err {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParseArray(t *testing.T) {
+	var data = []struct {
+		in    string
+		elems []arrayElem
+		ok    bool
+	}{
+		{"{}", []arrayElem{}, true},
+		{"{1,2,3}", []arrayElem{{text: "1"}, {text: "2"}, {text: "3"}}, true},
+		{` { a b , "c,\"d\"" , NULL,"NULL",\NULL } `, []arrayElem{{text: "a b"}, {text: `c,"d"`}, {null: true}, {text: "NULL"}, {text: "NULL"}}, true},
+		{`{{1,2},{NULL,"4"}}`, []arrayElem{
+			{nested: true, elems: []arrayElem{{text: "1"}, {text: "2"}}},
+			{nested: true, elems: []arrayElem{{null: true}, {text: "4"}}},
+		}, true},
+		{"[0:1]={1,2}", []arrayElem{{text: "1"}, {text: "2"}}, true},
+		{`{""}`, []arrayElem{{text: ""}}, true},
+		{"", nil, false},
+		{"1,2", nil, false},
+		{"{1,2", nil, false},
+		{"{1,,2}", nil, false},
+		{`{"a}`, nil, false},
+		{"{1}2", nil, false},
+		{"{1 {2}}", nil, false},
+		{"[0:1]{1}", nil, false},
+	}
+	ass := assert.New(t)
+	for _, tc := range data {
+		elems, ok := parseArray(tc.in)
+		ass.Equal(tc.ok, ok, tc.in)
+		ass.Equal(tc.elems, elems, tc.in)
+	}
+}
+
+func TestScanArray(t *testing.T) {
+	type record struct {
+		IDs    []int64     `ddb:"ids"`
+		Tags   []string    `ddb:"tags"`
+		Scores []float64   `ddb:"scores"`
+		Matrix [][]int     `ddb:"matrix"`
+		Opt    []*string   `ddb:"opt"`
+		Days   []time.Time `ddb:"days"`
+		Set    *[]uint     `ddb:"set"`
+		Raw    []byte      `ddb:"raw"`
+	}
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	columns := []string{"ids", "tags", "scores", "matrix", "opt", "days", "set", "raw"}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(columns).AddRow(
+		[]byte("{1,2,3}"),
+		`{go,"a b","x,\"y\"",NULL}`,
+		[]byte("{1.5,-2}"),
+		[]byte("{{1,2},{3,4}}"),
+		[]byte(`{a,NULL}`),
+		[]byte(`{2018-01-02,"2018-01-03 04:05:06"}`),
+		[]byte("{}"),
+		[]byte("{1,2}"),
+	))
+	rows, err := db.Query("SELECT")
+	ass.NoError(err)
+	var rec record
+	ass.NoError(Scan(rows, &rec))
+	ass.NoError(rows.Close())
+	ass.Equal([]int64{1, 2, 3}, rec.IDs)
+	ass.Equal([]string{"go", "a b", `x,"y"`, ""}, rec.Tags)
+	ass.Equal([]float64{1.5, -2}, rec.Scores)
+	ass.Equal([][]int{{1, 2}, {3, 4}}, rec.Matrix)
+	a := "a"
+	ass.Equal([]*string{&a, nil}, rec.Opt)
+	ass.Equal([]time.Time{time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 3, 4, 5, 6, 0, time.UTC)}, rec.Days)
+	ass.Equal(&[]uint{}, rec.Set)
+	ass.Equal([]byte("{1,2}"), rec.Raw)
+
+	var data = []struct {
+		column string
+		value  interface{}
+		err    error
+	}{
+		{"ids", []byte("1,2"), newScanErr("record", "IDs", reflect.TypeOf([]byte{}), reflect.TypeOf([]int64{}))},
+		{"ids", []byte("{1,a}"), newScanErr("record", "IDs", reflect.TypeOf([]byte{}), reflect.TypeOf(int64(0)))},
+		{"ids", []byte("{{1},{2}}"), newScanErr("record", "IDs", reflect.TypeOf([]byte{}), reflect.TypeOf([]int64{}))},
+		{"matrix", []byte("{1,2}"), newScanErr("record", "Matrix", reflect.TypeOf([]byte{}), reflect.TypeOf([]int{}))},
+		{"tags", int64(1), newScanErr("record", "Tags", reflect.TypeOf(int64(0)), reflect.TypeOf([]string{}))},
+	}
+	for _, tc := range data {
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{tc.column}).AddRow(tc.value))
+		rows, err = db.Query("SELECT")
+		ass.NoError(err)
+		rec = record{}
+		ass.Equal(tc.err, Scan(rows, &rec), "%s: %v", tc.column, tc.value)
+		ass.NoError(rows.Close())
+	}
+	ass.NoError(mock.ExpectationsWereMet())
+}
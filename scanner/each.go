@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrStop can be returned by the fn of Each to stop iterating without an error
+var ErrStop = errors.New("[scanner]: stop iterating")
+
+// rowsErr returns the error met during the iteration if rows reports it like *sql.Rows does
+func rowsErr(rows Rows) error {
+	if r, ok := rows.(interface{ Err() error }); ok {
+		return r.Err()
+	}
+	return nil
+}
+
+// Each decodes the rows one at a time into target, which must be a pointer to struct(or to a pointer to struct) or it fails with ErrTargetNotStruct,
+// and calls fn after every row, so the whole result is never held in memory. target is reset to the zero value before each row.
+// iterating stops at the first error of fn, ErrStop stops it and Each returns nil.
+// rows are closed when Each returns
+func Each(rows Rows, target interface{}, fn func() error) error {
+	return Default().Each(rows, target, fn)
+}
+
+// Each is the same as the package level Each but follows the settings of s
+func (s *Scanner) Each(rows Rows, target interface{}, fn func() error) error {
+	if nil == rows {
+		return ErrNilRows
+	}
+	defer rows.Close()
+	if nil == target || reflect.TypeOf(target).Kind() != reflect.Ptr || reflect.ValueOf(target).IsNil() {
+		return ErrTargetNotSettable
+	}
+	columns, err := rows.Columns()
+	if nil != err {
+		return err
+	}
+	targetObj := reflect.ValueOf(target).Elem()
+	st, ptrs, ok := structType(targetObj.Type())
+	if !ok {
+		return ErrTargetNotStruct
+	}
+	d, err := s.newRowDecoder(rows, st, columns)
+	if nil != err {
+		return err
+	}
+	zero := reflect.Zero(targetObj.Type())
+	for rows.Next() {
+		if 0 == ptrs {
+			targetObj.Set(zero)
+			err = d.decode(rows, targetObj)
+		} else {
+			var obj reflect.Value
+			if obj, err = d.decodeNew(rows, targetObj.Type()); nil == err {
+				targetObj.Set(obj)
+			}
+		}
+		if nil != err {
+			return err
+		}
+		if err = fn(); nil != err {
+			if err == ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+	return rowsErr(rows)
+}
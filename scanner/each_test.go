@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// closedRows records whether the rows are closed
+type closedRows struct {
+	*fakeRows
+	closed bool
+}
+
+func (r *closedRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+type eachBoy struct {
+	Name string  `ddb:"name"`
+	Age  int     `ddb:"age"`
+	Nick *string `ddb:"nick"`
+}
+
+func newEachRows() *closedRows {
+	nick := "dd"
+	return &closedRows{fakeRows: &fakeRows{
+		columns: []string{"name", "age", "nick"},
+		dataset: [][]interface{}{
+			{"deen", 23, &nick},
+			{"caibirdme", 24, (*string)(nil)},
+			{"tony", 25, (*string)(nil)},
+		},
+	}}
+}
+
+func TestEach(t *testing.T) {
+	ass := assert.New(t)
+	rows := newEachRows()
+	var boy eachBoy
+	var boys []eachBoy
+	err := Each(rows, &boy, func() error {
+		boys = append(boys, boy)
+		return nil
+	})
+	ass.NoError(err)
+	ass.True(rows.closed)
+	ass.Len(boys, 3)
+	ass.Equal("dd", *boys[0].Nick)
+	// reset before every row, so nothing is left over from the previous one
+	ass.Equal(eachBoy{Name: "caibirdme", Age: 24}, boys[1])
+
+	rows = newEachRows()
+	var names []string
+	err = Each(rows, &boy, func() error {
+		names = append(names, boy.Name)
+		if len(names) == 2 {
+			return ErrStop
+		}
+		return nil
+	})
+	ass.NoError(err)
+	ass.True(rows.closed)
+	ass.Equal([]string{"deen", "caibirdme"}, names)
+
+	rows = newEachRows()
+	fnErr := errors.New("write failed")
+	ass.Equal(fnErr, Each(rows, &boy, func() error { return fnErr }))
+	ass.True(rows.closed)
+
+	rows = newEachRows()
+	var ptrBoy *eachBoy
+	var ptrBoys []*eachBoy
+	ass.NoError(Each(rows, &ptrBoy, func() error {
+		ptrBoys = append(ptrBoys, ptrBoy)
+		return nil
+	}))
+	ass.Len(ptrBoys, 3)
+	ass.Equal("tony", ptrBoys[2].Name)
+	ass.Equal("deen", ptrBoys[0].Name)
+
+	rows = &closedRows{fakeRows: &fakeRows{columns: []string{"age"}, dataset: [][]interface{}{{"x"}}}}
+	ass.Error(Each(rows, &boy, func() error { return nil }))
+	ass.True(rows.closed)
+
+	rows = newEachRows()
+	ass.Equal(ErrTargetNotSettable, Each(rows, boy, func() error { return nil }))
+	ass.True(rows.closed)
+	rows = newEachRows()
+	var age int
+	ass.Equal(ErrTargetNotStruct, Each(rows, &age, func() error { return nil }))
+	ass.True(rows.closed)
+	ass.Equal(ErrNilRows, Each(nil, &boy, func() error { return nil }))
+}
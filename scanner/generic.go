@@ -0,0 +1,20 @@
+package scanner
+
+// ScanAll is the same as Scan but returns the rows as []T
+// Don't forget to close the rows
+func ScanAll[T any](rows Rows) ([]T, error) {
+	var result []T
+	if err := Scan(rows, &result); nil != err {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ScanOne is the same as Scan but returns the first row as a T, which must be a struct.
+// ErrEmptyResult is returned if the query result is empty
+// Don't forget to close the rows
+func ScanOne[T any](rows Rows) (T, error) {
+	var result T
+	err := Scan(rows, &result)
+	return result, err
+}
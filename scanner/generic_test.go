@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestScanAllAndOne(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	type person struct {
+		Name string `ddb:"name"`
+		Age  int    `ddb:"age"`
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name", "age"}).
+		AddRow("deen", int64(23)).AddRow("tony", int64(30)))
+	rows, err := db.Query("SELECT name,age FROM person")
+	ass.NoError(err)
+	persons, err := ScanAll[person](rows)
+	ass.NoError(err)
+	ass.Equal([]person{{"deen", 23}, {"tony", 30}}, persons)
+	ass.NoError(rows.Close())
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name", "age"}).AddRow("deen", int64(23)))
+	rows, err = db.Query("SELECT name,age FROM person")
+	ass.NoError(err)
+	p, err := ScanOne[person](rows)
+	ass.NoError(err)
+	ass.Equal(person{"deen", 23}, p)
+	ass.NoError(rows.Close())
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name", "age"}))
+	rows, err = db.Query("SELECT name,age FROM person")
+	ass.NoError(err)
+	_, err = ScanOne[person](rows)
+	ass.Equal(ErrEmptyResult, err)
+	ass.NoError(rows.Close())
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name", "age"}))
+	rows, err = db.Query("SELECT name,age FROM person")
+	ass.NoError(err)
+	persons, err = ScanAll[person](rows)
+	ass.NoError(err)
+	ass.Nil(persons)
+	ass.NoError(rows.Close())
+	ass.NoError(mock.ExpectationsWereMet())
+}
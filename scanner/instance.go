@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConvertFunc converts src, the value of a column(nil for NULL), into dst, which is a settable field
+type ConvertFunc func(src interface{}, dst reflect.Value) error
+
+// config holds the settings of a Scanner, it's never changed once the Scanner is created
+type config struct {
+	tagName string
+	// nameMapper maps the name of an untagged field to its column, nil skips the untagged fields
+	nameMapper func(field string) string
+	// caseInsensitive matches the columns ignoring case
+	caseInsensitive bool
+	// converters are keyed by the type of the field
+	converters map[reflect.Type]ConvertFunc
+	// strict fails a struct scan if a column isn't bound to any field
+	strict       bool
+	timeLayouts  []string
+	timeFormat   string
+	timeLocation *time.Location
+}
+
+// Scanner scans rows and maps structs following its own settings, so libraries using different tags don't conflict.
+// it's safe for concurrent use. the package level functions use the Default Scanner
+type Scanner struct {
+	config
+	// the fieldPlans built by this Scanner, keyed by planKey
+	plans sync.Map
+	// the []Column listed by Columns, keyed by the struct type
+	columns sync.Map
+}
+
+// Option configures a Scanner
+type Option func(*config)
+
+// New returns a Scanner with the given options, the others are the same as the package level defaults:
+// tag ddb, untagged fields skipped, case sensitive, not strict, DefaultTimeLayouts, 2006-01-02 15:04:05 and the location given by the driver
+func New(opts ...Option) *Scanner {
+	s := &Scanner{config: config{
+		tagName:     DefaultTagName,
+		timeLayouts: DefaultTimeLayouts,
+		timeFormat:  cTimeFormat,
+	}}
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+	return s
+}
+
+// With returns a new Scanner with the settings of s changed by opts, s itself is left untouched
+func (s *Scanner) With(opts ...Option) *Scanner {
+	n := &Scanner{config: s.config}
+	for _, opt := range opts {
+		opt(&n.config)
+	}
+	return n
+}
+
+// WithTagName sets the struct tag the columns are looked up by, an empty name keeps ddb
+func WithTagName(name string) Option {
+	return func(c *config) {
+		if "" != name {
+			c.tagName = name
+		}
+	}
+}
+
+// WithNameMapper binds an untagged exported field to the column fn returns for the name of the field,
+// and Map keys it by the column. SnakeCase and LowerCamel are the common ones, a field tagged with "-" is still skipped.
+// two untagged fields mapped to the same column fail with ErrNameConflict
+func WithNameMapper(fn func(field string) string) Option {
+	return func(c *config) {
+		c.nameMapper = fn
+	}
+}
+
+// WithConverter makes fn convert the columns into the fields of type typ, instead of the built-in rules
+func WithConverter(typ reflect.Type, fn ConvertFunc) Option {
+	return func(c *config) {
+		// copy on write, the map may be shared with the Scanner this one is made from
+		converters := make(map[reflect.Type]ConvertFunc, len(c.converters)+1)
+		for t, f := range c.converters {
+			converters[t] = f
+		}
+		converters[typ] = fn
+		c.converters = converters
+	}
+}
+
+// WithStrict makes scanning into a struct fail with ErrUnboundColumn if a column isn't bound to any field
+func WithStrict(strict bool) Option {
+	return func(c *config) {
+		c.strict = strict
+	}
+}
+
+// WithTimeLayouts sets the layouts a text column is parsed into time.Time with, they're tried in order
+func WithTimeLayouts(layouts ...string) Option {
+	return func(c *config) {
+		c.timeLayouts = layouts
+	}
+}
+
+// WithTimeFormat sets the layout a time.Time is formatted with into a string field
+func WithTimeFormat(format string) Option {
+	return func(c *config) {
+		c.timeFormat = format
+	}
+}
+
+// WithTimeLocation sets the location every time.Time is converted into before binding,
+// the text without a zone is parsed in it as well. nil keeps the location given by the driver
+func WithTimeLocation(loc *time.Location) Option {
+	return func(c *config) {
+		c.timeLocation = loc
+	}
+}
+
+var (
+	defaultScanner atomic.Value
+	// defaultMu serializes the changes of the default Scanner
+	defaultMu  sync.Mutex
+	tagNameSet bool
+)
+
+func init() {
+	defaultScanner.Store(New())
+}
+
+// Default returns the Scanner used by the package level functions
+func Default() *Scanner {
+	return defaultScanner.Load().(*Scanner)
+}
+
+// SetDefault replaces the Scanner used by the package level functions with s, nil restores New().
+// the Scanners already returned by Default keep their settings
+func SetDefault(s *Scanner) {
+	if nil == s {
+		s = New()
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultScanner.Store(s)
+}
@@ -0,0 +1,187 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestScannerTagName(t *testing.T) {
+	type user struct {
+		Name string `db:"name" ddb:"nick"`
+		Age  int    `db:"age" ddb:"years"`
+	}
+	ass := assert.New(t)
+	db := New(WithTagName("db"))
+	ddb := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var u user
+			rows := &fakeRows{columns: []string{"name", "age"}, dataset: [][]interface{}{{"deen", int64(23)}}}
+			ass.NoError(db.Scan(rows, &u))
+			ass.Equal(user{"deen", 23}, u)
+		}()
+		go func() {
+			defer wg.Done()
+			var u user
+			rows := &fakeRows{columns: []string{"nick", "years"}, dataset: [][]interface{}{{"dd", int64(24)}}}
+			ass.NoError(ddb.Scan(rows, &u))
+			ass.Equal(user{"dd", 24}, u)
+		}()
+	}
+	wg.Wait()
+
+	result, err := db.Map(user{"deen", 23})
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"name": "deen", "age": 23}, result)
+	ass.Equal(DefaultTagName, New(WithTagName("")).tagName)
+}
+
+func TestScannerWith(t *testing.T) {
+	ass := assert.New(t)
+	upper := func(src interface{}, dst reflect.Value) error {
+		dst.SetString(strings.ToUpper(string(src.([]byte))))
+		return nil
+	}
+	base := New(WithTimeFormat(time.RFC3339))
+	derived := base.With(WithConverter(reflect.TypeOf(""), upper), WithStrict(true))
+	ass.Nil(base.converters)
+	ass.False(base.strict)
+	ass.Len(derived.converters, 1)
+	ass.Equal(time.RFC3339, derived.timeFormat)
+	again := derived.With(WithConverter(reflect.TypeOf(0), nil))
+	ass.Len(derived.converters, 1)
+	ass.Len(again.converters, 2)
+}
+
+func TestSetDefault(t *testing.T) {
+	defer resetDefault()
+	ass := assert.New(t)
+	old := Default()
+	s := New(WithTagName("db"))
+	SetDefault(s)
+	ass.True(s == Default())
+	ass.Equal(DefaultTagName, old.tagName)
+	SetDefault(nil)
+	ass.False(s == Default())
+	ass.Equal(DefaultTagName, Default().tagName)
+}
+
+func TestScannerConverter(t *testing.T) {
+	type record struct {
+		Name  string  `ddb:"name"`
+		Alias *string `ddb:"alias"`
+		Flag  bool    `ddb:"flag"`
+	}
+	ass := assert.New(t)
+	errFlag := errors.New("bad flag")
+	s := New(
+		WithConverter(reflect.TypeOf(""), func(src interface{}, dst reflect.Value) error {
+			if nil == src {
+				dst.SetString("<null>")
+				return nil
+			}
+			dst.SetString(strings.ToUpper(string(src.([]byte))))
+			return nil
+		}),
+		WithConverter(reflect.TypeOf(true), func(src interface{}, dst reflect.Value) error {
+			switch string(src.([]byte)) {
+			case "t":
+				dst.SetBool(true)
+			case "f":
+				dst.SetBool(false)
+			default:
+				return errFlag
+			}
+			return nil
+		}),
+	)
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name", "alias", "flag"}).
+		AddRow([]byte("deen"), []byte("dd"), []byte("t")).
+		AddRow(nil, nil, []byte("f")))
+	var records []record
+	ass.NoError(s.Query(context.Background(), db, &records, "SELECT"))
+	ass.NoError(mock.ExpectationsWereMet())
+	dd := "DD"
+	ass.Equal([]record{{"DEEN", &dd, true}, {"<null>", nil, false}}, records)
+
+	rows := &fakeRows{columns: []string{"flag"}, dataset: [][]interface{}{{[]byte("x")}}}
+	var rec record
+	ass.Equal(errFlag, s.Scan(rows, &rec))
+	// the default Scanner isn't affected
+	rows = &fakeRows{columns: []string{"name"}, dataset: [][]interface{}{{[]byte("deen")}}}
+	ass.NoError(Scan(rows, &rec))
+	ass.Equal("deen", rec.Name)
+}
+
+func TestScannerStrict(t *testing.T) {
+	type user struct {
+		Name string `ddb:"name"`
+	}
+	ass := assert.New(t)
+	s := New(WithStrict(true))
+	columns := []string{"name", "age"}
+	var users []user
+	err := s.Scan(&fakeRows{columns: columns, dataset: [][]interface{}{{"deen", int64(1)}}}, &users)
+	ass.True(errors.Is(err, ErrUnboundColumn))
+	ass.EqualError(err, "[scanner]: column isn't bound to any field: age of user")
+	var u user
+	ass.True(errors.Is(s.Scan(&fakeRows{columns: columns, dataset: [][]interface{}{{"deen", int64(1)}}}, &u), ErrUnboundColumn))
+	ass.True(errors.Is(s.Each(&fakeRows{columns: columns}, &u, func() error { return nil }), ErrUnboundColumn))
+	ass.NoError(New().Scan(&fakeRows{columns: columns, dataset: [][]interface{}{{"deen", int64(1)}}}, &users))
+	ass.NoError(s.Scan(&fakeRows{columns: []string{"name"}, dataset: [][]interface{}{{"deen"}}}, &users))
+	ass.Equal([]user{{"deen"}}, users)
+}
+
+func TestScannerNameMapper(t *testing.T) {
+	type user struct {
+		UserName string
+		Age      int `ddb:"years"`
+		Skipped  int `ddb:"-"`
+		private  int
+	}
+	ass := assert.New(t)
+	s := New(WithNameMapper(strings.ToLower))
+	rows := &fakeRows{columns: []string{"username", "years", "skipped", "private"}, dataset: [][]interface{}{{"deen", int64(23), int64(1), int64(2)}}}
+	var u user
+	ass.NoError(s.Scan(rows, &u))
+	ass.Equal(user{UserName: "deen", Age: 23}, u)
+	result, err := s.Map(u)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"username": "deen", "years": 23}, result)
+}
+
+func TestScannerTime(t *testing.T) {
+	type record struct {
+		Created time.Time `ddb:"created"`
+		Str     string    `ddb:"str"`
+	}
+	ass := assert.New(t)
+	shanghai := time.FixedZone("CST", 8*3600)
+	s := New(WithTimeLayouts("2006/01/02"), WithTimeFormat("2006-01-02"), WithTimeLocation(shanghai))
+	created := time.Date(2018, 1, 2, 20, 0, 0, 0, time.UTC)
+	rows := &fakeRows{columns: []string{"created", "str"}, dataset: [][]interface{}{{[]byte("2018/01/03"), created}}}
+	var rec record
+	ass.NoError(s.Scan(rows, &rec))
+	ass.Equal(time.Date(2018, 1, 3, 0, 0, 0, 0, shanghai), rec.Created)
+	ass.Equal("2018-01-03", rec.Str)
+	// the default Scanner keeps its own settings
+	rows = &fakeRows{columns: []string{"str"}, dataset: [][]interface{}{{created}}}
+	ass.NoError(Scan(rows, &rec))
+	ass.Equal("2018-01-02 20:00:00", rec.Str)
+}
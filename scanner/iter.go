@@ -0,0 +1,28 @@
+package scanner
+
+import "iter"
+
+// Iterate returns an iterator decoding the rows one at a time into T, as Each does.
+// an error is yielded with the zero T and ends the iteration.
+// rows are closed when the iteration ends, including when the loop breaks early
+//
+//	for user, err := range scanner.Iterate[User](rows) {
+//		if nil != err {
+//			return err
+//		}
+//	}
+func Iterate[T any](rows Rows) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var row T
+		err := Each(rows, &row, func() error {
+			if !yield(row, nil) {
+				return ErrStop
+			}
+			return nil
+		})
+		if nil != err {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterate(t *testing.T) {
+	ass := assert.New(t)
+	rows := newEachRows()
+	var names []string
+	for boy, err := range Iterate[eachBoy](rows) {
+		ass.NoError(err)
+		names = append(names, boy.Name)
+	}
+	ass.Equal([]string{"deen", "caibirdme", "tony"}, names)
+	ass.True(rows.closed)
+
+	rows = newEachRows()
+	for boy, err := range Iterate[*eachBoy](rows) {
+		ass.NoError(err)
+		ass.Equal("deen", boy.Name)
+		break
+	}
+	ass.True(rows.closed)
+
+	rows = &closedRows{fakeRows: &fakeRows{columns: []string{"age"}, dataset: [][]interface{}{{"x"}, {"y"}}}}
+	var errs int
+	for boy, err := range Iterate[eachBoy](rows) {
+		ass.Error(err)
+		ass.Equal(eachBoy{}, boy)
+		errs++
+	}
+	ass.Equal(1, errs)
+	ass.True(rows.closed)
+}
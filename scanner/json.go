@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// jsonTagOption makes a field a json column, ie: `ddb:"settings,json"`
+const jsonTagOption = "json"
+
+// convertJSON unmarshals the json column mapValue into valuei, which could be of any type.
+// a value other than text, as some drivers decode json themselves, is marshaled back first.
+// NULL follows the same rule as the other fields
+func (s *Scanner) convertJSON(mapValue interface{}, valuei reflect.Value, tc *typeConv, wrapErr func(from, to reflect.Type) ScanErr) error {
+	if nil == mapValue {
+		return s.convert(nil, valuei, tc, wrapErr)
+	}
+	var data []byte
+	switch v := mapValue.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); nil != err {
+			return wrapErr(reflect.TypeOf(mapValue), valuei.Type())
+		}
+	}
+	// unmarshal into a new value so that a map or a slice isn't merged with the old one
+	obj := reflect.New(valuei.Type())
+	if err := json.Unmarshal(data, obj.Interface()); nil != err {
+		return fmt.Errorf("[scanner]: fail to unmarshal the json into %s, err: %w", valuei.Type(), err)
+	}
+	valuei.Set(obj.Elem())
+	return nil
+}
+
+// jsonValue marshals fv into a json string, a nil pointer, interface, map or slice is NULL
+func jsonValue(fv reflect.Value) (interface{}, error) {
+	switch fv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if fv.IsNil() {
+			return nil, nil
+		}
+	}
+	b, err := json.Marshal(fv.Interface())
+	if nil != err {
+		return nil, fmt.Errorf("[scanner]: fail to marshal %s into json, err: %w", fv.Type(), err)
+	}
+	return string(b), nil
+}
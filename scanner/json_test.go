@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type settings struct {
+	Theme  string `json:"theme"`
+	Notify bool   `json:"notify"`
+}
+
+type profile struct {
+	ID       int64                  `ddb:"id"`
+	Settings settings               `ddb:"settings,json"`
+	Labels   map[string]interface{} `ddb:"labels,json"`
+	Tags     []string               `ddb:"tags,json"`
+	Extra    *settings              `ddb:"extra,json"`
+}
+
+func TestScanJSON(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	columns := []string{"id", "settings", "labels", "tags", "extra"}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(int64(1), []byte(`{"theme":"dark","notify":true}`), []byte(`{"a":1}`), `["x","y"]`, []byte(`{"theme":"light"}`)).
+		AddRow(int64(2), []byte(`{}`), nil, []byte(`null`), nil))
+	rows, err := db.Query("SELECT")
+	ass.NoError(err)
+	var profiles []profile
+	ass.NoError(Scan(rows, &profiles))
+	ass.NoError(rows.Close())
+	ass.Equal([]profile{
+		{1, settings{"dark", true}, map[string]interface{}{"a": float64(1)}, []string{"x", "y"}, &settings{Theme: "light"}},
+		{ID: 2},
+	}, profiles)
+
+	// a Rows other than *sql.Rows may give a decoded value, it's marshaled back first and the old map isn't merged
+	p := profile{Labels: map[string]interface{}{"old": true}}
+	ass.NoError(bindMap(map[string]interface{}{"labels": map[string]interface{}{"b": "c"}, "settings": `{"theme":"blue"}`}, &p))
+	ass.Equal(map[string]interface{}{"b": "c"}, p.Labels)
+	ass.Equal(settings{Theme: "blue"}, p.Settings)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"settings"}).AddRow([]byte(`{"theme":`)))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	ass.Error(Scan(rows, &p))
+	ass.NoError(rows.Close())
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+func TestMapJSON(t *testing.T) {
+	ass := assert.New(t)
+	result, err := Map(profile{ID: 1, Settings: settings{Theme: "dark"}, Tags: []string{"x"}}, DefaultTagName)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{
+		"id":       int64(1),
+		"settings": `{"theme":"dark","notify":false}`,
+		"labels":   nil,
+		"tags":     `["x"]`,
+		"extra":    nil,
+	}, result)
+
+	_, err = Map(struct {
+		Ch chan int `ddb:"ch,json"`
+	}{make(chan int)}, DefaultTagName)
+	ass.Error(err)
+}
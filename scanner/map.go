@@ -1,7 +1,9 @@
 package scanner
 
 import (
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -12,8 +14,20 @@ var (
 )
 
 // Map converts a struct to a map
-// type for each field of the struct must be built-in type
+// type for each field of the struct must be built-in type, a pointer field is resolved to the value it points to or nil.
+// a field with the json option of useTag is marshaled into a json string, a field tagged with "-" is skipped.
+// an untagged field is keyed as the default Scanner binds it, see WithNameMapper, WithCaseInsensitive and SetDefault
 func Map(target interface{}, useTag string) (map[string]interface{}, error) {
+	return mapStruct(target, useTag, &Default().config)
+}
+
+// Map is the same as the package level Map with the tag name of s,
+// an untagged field is keyed as s maps it
+func (s *Scanner) Map(target interface{}) (map[string]interface{}, error) {
+	return mapStruct(target, s.tagName, &s.config)
+}
+
+func mapStruct(target interface{}, useTag string, c *config) (map[string]interface{}, error) {
 	if nil == target {
 		return nil, nil
 	}
@@ -26,35 +40,88 @@ func Map(target interface{}, useTag string) (map[string]interface{}, error) {
 	}
 	t := v.Type()
 	result := make(map[string]interface{})
+	// the fields keyed so far, to detect the conflicts of mapped keys
+	keyed := make(map[string]reflect.StructField)
 	for i := 0; i < t.NumField(); i++ {
-		keyName := getKey(t.Field(i), useTag)
+		field := t.Field(i)
+		keyName, mapped := getKey(field, useTag, c)
 		if "" == keyName {
 			continue
 		}
-		result[keyName] = v.Field(i).Interface()
+		if other, ok := keyed[keyName]; ok {
+			if _, tagged := other.Tag.Lookup(useTag); mapped || !tagged {
+				return nil, fmt.Errorf("%w: %s,%s of %s are mapped to %s", ErrNameConflict, other.Name, field.Name, t.Name(), keyName)
+			}
+		}
+		keyed[keyName] = field
+		var val interface{}
+		var err error
+		if "" != useTag && hasTagOption(field.Tag.Get(useTag), jsonTagOption) {
+			val, err = jsonValue(v.Field(i))
+		} else {
+			val, err = fieldValue(v.Field(i))
+		}
+		if nil != err {
+			return nil, err
+		}
+		result[keyName] = val
 	}
 	return result, nil
 }
 
+var driverValuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// fieldValue returns the value of fv, a driver.Valuer(or whose address is) is converted by Value,
+// a pointer is resolved to the value it points to, or nil
+func fieldValue(fv reflect.Value) (interface{}, error) {
+	var valuer driver.Valuer
+	switch {
+	case (fv.Kind() == reflect.Interface || fv.Kind() == reflect.Ptr) && fv.IsNil():
+		return nil, nil
+	case fv.Kind() == reflect.Ptr:
+		return fieldValue(fv.Elem())
+	case fv.Type().Implements(driverValuerType):
+		valuer = fv.Interface().(driver.Valuer)
+	case fv.CanAddr() && reflect.PtrTo(fv.Type()).Implements(driverValuerType):
+		valuer = fv.Addr().Interface().(driver.Valuer)
+	default:
+		return fv.Interface(), nil
+	}
+	val, err := valuer.Value()
+	if nil != err {
+		return nil, fmt.Errorf("[scanner]: %s.Value fail to convert the value, err: %w", fv.Type(), err)
+	}
+	return val, nil
+}
+
 func isExportedField(name string) bool {
 	return strings.Title(name) == name
 }
 
-func getKey(field reflect.StructField, useTag string) string {
+// getKey returns the key of the field in the result of Map, and whether the key is mapped by c
+func getKey(field reflect.StructField, useTag string, c *config) (string, bool) {
 	if !isExportedField(field.Name) {
-		return ""
-	}
-	if field.Type.Kind() == reflect.Ptr {
-		return ""
+		return "", false
 	}
 	if "" == useTag {
-		return field.Name
+		return field.Name, false
 	}
 	tag, ok := field.Tag.Lookup(useTag)
+	if ok {
+		if name := resolveTagName(tag); "-" != name {
+			return name, false
+		}
+		return "", false
+	}
+	// keyed by the same name Scan binds the field to, so the result scans back into the struct
+	if field.Anonymous {
+		return "", false
+	}
+	name, ok := c.mapName(field.Name)
 	if !ok {
-		return ""
+		return "", false
 	}
-	return resolveTagName(tag)
+	return name, true
 }
 
 func resolveTagName(tag string) string {
@@ -64,3 +131,26 @@ func resolveTagName(tag string) string {
 	}
 	return tag[:idx]
 }
+
+// lookUpTagOption returns the value of option key=value of tag, ie: prefix of "user,prefix=user_"
+func lookUpTagOption(tag, key string) (string, bool) {
+	opts := strings.Split(tag, ",")
+	for _, opt := range opts[1:] {
+		opt = strings.TrimSpace(opt)
+		if strings.HasPrefix(opt, key+"=") {
+			return opt[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// hasTagOption reports whether tag has the option opt, ie: json of "settings,json"
+func hasTagOption(tag, opt string) bool {
+	opts := strings.Split(tag, ",")
+	for _, o := range opts[1:] {
+		if strings.TrimSpace(o) == opt {
+			return true
+		}
+	}
+	return false
+}
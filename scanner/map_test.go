@@ -1,6 +1,9 @@
 package scanner
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -26,8 +29,13 @@ func TestMap(t *testing.T) {
 	var ok bool
 	_, ok = mapA["foo"]
 	ass.False(ok)
-	_, ok = mapA["cc"]
-	ass.False(ok)
+	ass.Equal(5, mapA["cc"])
+	a.C = nil
+	mapA, err = Map(a, DefaultTagName)
+	ass.NoError(err)
+	v, ok := mapA["cc"]
+	ass.True(ok)
+	ass.Nil(v)
 }
 
 func TestStructWithPointer(t *testing.T) {
@@ -72,3 +80,29 @@ func TestNonStructInput(t *testing.T) {
 	ass.Nil(m)
 	ass.Equal(ErrNoneStructTarget, err)
 }
+
+// money is stored as cents
+type money float64
+
+func (m money) Value() (driver.Value, error) {
+	if m < 0 {
+		return nil, errors.New("negative money")
+	}
+	return int64(m * 100), nil
+}
+
+func TestMapValuer(t *testing.T) {
+	type order struct {
+		Price  money          `ddb:"price"`
+		Remark sql.NullString `ddb:"remark"`
+		Note   sql.NullString `ddb:"note"`
+		Any    driver.Valuer  `ddb:"any"`
+	}
+	ass := assert.New(t)
+	m, err := Map(order{Price: 1.5, Remark: sql.NullString{String: "fast", Valid: true}}, DefaultTagName)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"price": int64(150), "remark": "fast", "note": nil, "any": nil}, m)
+
+	_, err = Map(&order{Price: -1}, DefaultTagName)
+	ass.EqualError(err, "[scanner]: scanner.money.Value fail to convert the value, err: negative money")
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrNameConflict occurs when two fields without a tag are mapped to the same column
+var ErrNameConflict = errors.New("[scanner]: fields are mapped to the same column")
+
+// SnakeCase maps a field name to snake_case, acronyms are kept as one word, ie: UserID to user_id, HTTPServer to http_server
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) && '_' != runes[i-1] {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// LowerCamel maps a field name to lowerCamelCase, a leading acronym is lowered as a whole, ie: UserID to userID, HTTPServer to httpServer
+func LowerCamel(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	// the last upper of an acronym begins the next word
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// WithCaseInsensitive matches the columns to the names of the fields ignoring case,
+// an untagged field is bound by its own name if there's no nameMapper, and Map keys it by that name as well
+func WithCaseInsensitive(caseInsensitive bool) Option {
+	return func(c *config) {
+		c.caseInsensitive = caseInsensitive
+	}
+}
+
+// mapName returns the column of the untagged field, it reports false if the untagged fields are skipped
+func (c *config) mapName(field string) (string, bool) {
+	switch {
+	case nil != c.nameMapper:
+		return c.nameMapper(field), true
+	case c.caseInsensitive:
+		return field, true
+	}
+	return "", false
+}
+
+// matchName reports whether column matches one of names, and whether it matches exactly
+func (c *config) matchName(column string, names []string) (matched, exact bool) {
+	if isStringInSlice(column, names) {
+		return true, true
+	}
+	if !c.caseInsensitive {
+		return false, false
+	}
+	for _, name := range names {
+		if strings.EqualFold(column, name) {
+			return true, false
+		}
+	}
+	return false, false
+}
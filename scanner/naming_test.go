@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestSnakeCase(t *testing.T) {
+	var data = []struct {
+		in, snake, camel string
+	}{
+		{"Name", "name", "name"},
+		{"UserName", "user_name", "userName"},
+		{"UserID", "user_id", "userID"},
+		{"ID", "id", "id"},
+		{"HTTPServer", "http_server", "httpServer"},
+		{"APIKey2", "api_key2", "apiKey2"},
+		{"Address2Line", "address2_line", "address2Line"},
+		{"User_Name", "user_name", "user_Name"},
+		{"name", "name", "name"},
+		{"", "", ""},
+	}
+	ass := assert.New(t)
+	for _, tc := range data {
+		ass.Equal(tc.snake, SnakeCase(tc.in), tc.in)
+		ass.Equal(tc.camel, LowerCamel(tc.in), tc.in)
+	}
+}
+
+type mappedUser struct {
+	ID        int64
+	UserName  string
+	Nick      string `ddb:"nick_name"`
+	Skipped   string `ddb:"-"`
+	CreatedAt int64
+	private   int
+}
+
+func TestScanNameMapping(t *testing.T) {
+	ass := assert.New(t)
+	var data = []struct {
+		scanner *Scanner
+		columns []string
+	}{
+		{New(WithNameMapper(SnakeCase)), []string{"id", "user_name", "nick_name", "skipped", "created_at", "private"}},
+		{New(WithNameMapper(LowerCamel)), []string{"id", "userName", "nick_name", "Skipped", "createdAt", "private"}},
+		{New(WithCaseInsensitive(true)), []string{"ID", "username", "NICK_NAME", "skipped", "CREATEDAT", "private"}},
+		{New(WithNameMapper(SnakeCase), WithCaseInsensitive(true)), []string{"Id", "USER_NAME", "Nick_Name", "skipped", "created_AT", "private"}},
+		{New(WithNameMapper(func(field string) string { return "c_" + strings.ToLower(field) })), []string{"c_id", "c_username", "nick_name", "c_skipped", "c_createdat", "c_private"}},
+	}
+	for idx, tc := range data {
+		rows := &fakeRows{columns: tc.columns, dataset: [][]interface{}{{int64(1), "deen", "dd", "x", int64(2), int64(3)}}}
+		var users []mappedUser
+		ass.NoError(tc.scanner.Scan(rows, &users), "idx:%d", idx)
+		ass.Equal([]mappedUser{{ID: 1, UserName: "deen", Nick: "dd", CreatedAt: 2}}, users, "idx:%d", idx)
+	}
+	// untagged fields are skipped by default
+	var user mappedUser
+	rows := &fakeRows{columns: []string{"user_name", "nick_name"}, dataset: [][]interface{}{{"deen", "dd"}}}
+	ass.NoError(Scan(rows, &user))
+	ass.Equal(mappedUser{Nick: "dd"}, user)
+}
+
+func TestNameConflict(t *testing.T) {
+	type conflicted struct {
+		UserID   int64
+		UserId   int64
+		Name     string `ddb:"name"`
+		Alias    string `ddb:"name"`
+		FullName string
+	}
+	ass := assert.New(t)
+	s := New(WithNameMapper(SnakeCase))
+	rows := &fakeRows{columns: []string{"user_id"}, dataset: [][]interface{}{{int64(1)}}}
+	var c conflicted
+	err := s.Scan(rows, &c)
+	ass.True(errors.Is(err, ErrNameConflict))
+	ass.EqualError(err, "[scanner]: fields are mapped to the same column: UserID,UserId of conflicted are mapped to user_id")
+
+	// the fields tagged the same are bound together as before
+	rows = &fakeRows{columns: []string{"name", "full_name"}, dataset: [][]interface{}{{"deen", "dd"}}}
+	ass.NoError(s.Scan(rows, &c))
+	ass.Equal(conflicted{Name: "deen", Alias: "deen", FullName: "dd"}, c)
+
+	// a tag and a mapped name, or two tags matched ignoring case
+	type tagged struct {
+		Name     string `ddb:"full_name"`
+		Upper    string `ddb:"FULL_NAME"`
+		FullName string
+	}
+	rows = &fakeRows{columns: []string{"full_name"}, dataset: [][]interface{}{{"deen"}}}
+	var tg tagged
+	ass.True(errors.Is(s.Scan(rows, &tg), ErrNameConflict))
+	rows = &fakeRows{columns: []string{"full_name"}, dataset: [][]interface{}{{"deen"}}}
+	ass.True(errors.Is(New(WithCaseInsensitive(true)).Scan(rows, &tg), ErrNameConflict))
+
+	_, err = s.Map(conflicted{})
+	ass.True(errors.Is(err, ErrNameConflict))
+	_, err = s.Map(tagged{})
+	ass.True(errors.Is(err, ErrNameConflict))
+	result, err := Map(conflicted{Name: "deen", Alias: "dd"}, DefaultTagName)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"name": "dd"}, result)
+}
+
+func TestMapRoundTrip(t *testing.T) {
+	ass := assert.New(t)
+	for _, s := range []*Scanner{New(WithCaseInsensitive(true)), New(WithNameMapper(SnakeCase)), New(WithCaseInsensitive(true), WithNameMapper(LowerCamel))} {
+		rows := &fakeRows{columns: []string{"id", "user_name", "nick_name", "created_at"}, dataset: [][]interface{}{{int64(1), "deen", "dd", int64(2)}}}
+		var scanned mappedUser
+		ass.NoError(s.Scan(rows, &scanned))
+		ass.NotEqual(mappedUser{Nick: "dd"}, scanned)
+		result, err := s.Map(scanned)
+		ass.NoError(err)
+		var row []interface{}
+		rows = &fakeRows{}
+		for column, val := range result {
+			rows.columns = append(rows.columns, column)
+			row = append(row, val)
+		}
+		rows.dataset = [][]interface{}{row}
+		var again mappedUser
+		ass.NoError(s.Scan(rows, &again))
+		ass.Equal(scanned, again)
+	}
+}
+
+func TestMapNameMapping(t *testing.T) {
+	defer resetDefault()
+	ass := assert.New(t)
+	user := mappedUser{ID: 1, UserName: "deen", Nick: "dd", CreatedAt: 2, private: 3}
+	result, err := New(WithNameMapper(LowerCamel)).Map(user)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"id": int64(1), "userName": "deen", "nick_name": "dd", "createdAt": int64(2)}, result)
+	// the untagged fields are keyed by their own names
+	result, err = New(WithCaseInsensitive(true)).Map(user)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"ID": int64(1), "UserName": "deen", "nick_name": "dd", "CreatedAt": int64(2)}, result)
+	result, err = New(WithCaseInsensitive(true), WithNameMapper(LowerCamel)).Map(user)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"id": int64(1), "userName": "deen", "nick_name": "dd", "createdAt": int64(2)}, result)
+
+	SetDefault(New(WithNameMapper(SnakeCase)))
+	result, err = Map(user, DefaultTagName)
+	ass.NoError(err)
+	ass.Equal(map[string]interface{}{"id": int64(1), "user_name": "deen", "nick_name": "dd", "created_at": int64(2)}, result)
+	// the name is only mapped when there's no tag of useTag
+	result, err = Map(user, "")
+	ass.NoError(err)
+	ass.Equal("deen", result["UserName"])
+
+	var scanned mappedUser
+	rows := &fakeRows{columns: []string{"id", "user_name"}, dataset: [][]interface{}{{int64(1), "deen"}}}
+	ass.NoError(Scan(rows, &scanned))
+	ass.Equal(mappedUser{ID: 1, UserName: "deen"}, scanned)
+
+	SetDefault(Default().With(WithCaseInsensitive(true)))
+	rows = &fakeRows{columns: []string{"ID", "USER_NAME"}, dataset: [][]interface{}{{int64(1), "deen"}}}
+	scanned = mappedUser{}
+	ass.NoError(Scan(rows, &scanned))
+	ass.Equal(mappedUser{ID: 1, UserName: "deen"}, scanned)
+
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"Id", "USER_NAME"}).AddRow(int64(2), "dd"))
+	sqlRows, err := db.Query("SELECT")
+	ass.NoError(err)
+	ass.NoError(Scan(sqlRows, &scanned))
+	ass.NoError(sqlRows.Close())
+	ass.Equal(mappedUser{ID: 2, UserName: "dd"}, scanned)
+	ass.NoError(mock.ExpectationsWereMet())
+}
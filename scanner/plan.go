@@ -0,0 +1,486 @@
+package scanner
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// planKey identifies a fieldPlan of a Scanner, a plan only depends on the struct type and the columns
+type planKey struct {
+	typ     reflect.Type
+	columns string
+}
+
+// planField is a field a column is bound to
+type planField struct {
+	index   []int
+	name    string
+	wrapErr func(from, to reflect.Type) ScanErr
+	// the column is json unmarshaled into the field
+	json bool
+	conv *typeConv
+}
+
+// fieldPlan maps the columns of a result to the fields of a struct,
+// fields[i] are the fields the i-th column is bound to, nil if there's none
+type fieldPlan struct {
+	typeName string
+	fields   [][]planField
+	// keepsBytes[i] reports whether a field of the i-th column keeps its []byte, which is copied first then
+	keepsBytes []bool
+	// the first column without any field, empty if every column is bound
+	unbound string
+	// the description of the untagged fields mapped to the same column, empty if there's none
+	conflict string
+}
+
+// getPlan returns the cached plan of struct type t and columns, the plan is built at the first time
+func (s *Scanner) getPlan(t reflect.Type, columns []string) *fieldPlan {
+	key := planKey{t, strings.Join(columns, "\x00")}
+	if plan, ok := s.plans.Load(key); ok {
+		return plan.(*fieldPlan)
+	}
+	plan, _ := s.plans.LoadOrStore(key, s.buildPlan(t, columns))
+	return plan.(*fieldPlan)
+}
+
+// planCandidate is a field some columns could be bound to
+type planCandidate struct {
+	planField
+	// the column names matching the field
+	names []string
+	// depth of embedding and nesting, the shallowest candidates of a column win
+	depth int
+	// the names come from mapName rather than a tag
+	mapped bool
+	// the whole tag of the field
+	tag string
+	// the column the field is listed by Columns, empty if it's only bound to the other names
+	column string
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	byteUnmarshalerType = reflect.TypeOf((*ByteUnmarshaler)(nil)).Elem()
+)
+
+// buildPlan binds the columns to the tagged fields of t, and to the untagged ones if s maps them.
+// anonymous embedded structs(or pointers to struct) without a tag are flattened,
+// a struct field also takes the columns named "tag.column", or "prefixcolumn" with the prefix option.
+// the fields tagged with the same name all take the column,
+// but a column taken by a mapped field, or matched ignoring case, by more than one field is a conflict
+func (s *Scanner) buildPlan(t reflect.Type, columns []string) *fieldPlan {
+	plan := &fieldPlan{
+		typeName:   t.Name(),
+		fields:     make([][]planField, len(columns)),
+		keepsBytes: make([]bool, len(columns)),
+	}
+	candidates := s.collectCandidates(t, nil, []string{""}, "", true, 0, map[reflect.Type]bool{})
+	built := make(map[reflect.Type]*typeConv)
+	for col, column := range columns {
+		depth := -1
+		// all the fields of the column are tagged with exactly the column
+		exact := true
+		for i := range candidates {
+			c := &candidates[i]
+			if depth != -1 && c.depth > depth {
+				continue
+			}
+			matched, exactly := s.matchName(column, c.names)
+			if !matched {
+				continue
+			}
+			if depth == -1 || c.depth < depth {
+				depth = c.depth
+				exact = true
+				plan.fields[col] = plan.fields[col][:0]
+			}
+			if nil == c.conv {
+				c.conv = s.typeConvOf(t.FieldByIndex(c.index).Type, built)
+			}
+			plan.fields[col] = append(plan.fields[col], c.planField)
+			exact = exact && exactly && !c.mapped
+		}
+		for _, f := range plan.fields[col] {
+			plan.keepsBytes[col] = plan.keepsBytes[col] || f.json || f.conv.keepsBytes
+		}
+		if nil == plan.fields[col] && "" == plan.unbound {
+			plan.unbound = column
+		}
+		if len(plan.fields[col]) > 1 && !exact && "" == plan.conflict {
+			names := make([]string, len(plan.fields[col]))
+			for i, f := range plan.fields[col] {
+				names[i] = f.name
+			}
+			plan.conflict = fmt.Sprintf("%s of %s are mapped to %s", strings.Join(names, ","), plan.typeName, column)
+		}
+	}
+	return plan
+}
+
+func isStringInSlice(str string, arr []string) bool {
+	for _, s := range arr {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// isNestable reports whether a field of type t could take the columns of its fields
+func isNestable(t reflect.Type) bool {
+	st, ptrs, ok := structType(t)
+	if !ok || ptrs > 1 || st == timeType {
+		return false
+	}
+	return !t.Implements(byteUnmarshalerType) && !reflect.PtrTo(st).Implements(byteUnmarshalerType)
+}
+
+// collectCandidates walks the fields of t, index is the index path of t and prefixes are the prefixes of its column names.
+// columnPrefix is the prefix of the columns listed by Columns, inColumns is false inside a struct field
+// without the prefix option, whose fields only take the "tag.column" names.
+// visiting holds the types nested on the path so a recursive type is nested only once
+func (s *Scanner) collectCandidates(t reflect.Type, index []int, prefixes []string, columnPrefix string, inColumns bool, depth int, visiting map[reflect.Type]bool) []planCandidate {
+	var candidates []planCandidate
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+		_, hasTag := sf.Tag.Lookup(s.tagName)
+		if sf.Anonymous && !hasTag {
+			st, ptrs, ok := structType(sf.Type)
+			// a nil pointer of an unexported type can't be allocated
+			if !ok || ptrs > 1 || (ptrs == 1 && sf.PkgPath != "") || visiting[st] {
+				continue
+			}
+			visiting[st] = true
+			candidates = append(candidates, s.collectCandidates(st, fieldIndex, prefixes, columnPrefix, inColumns, depth+1, visiting)...)
+			delete(visiting, st)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		tagName, tag, ok := s.lookUpTagName(sf)
+		if !ok || "" == tagName || "-" == tagName {
+			continue
+		}
+		mapped := !hasTag
+		names := make([]string, len(prefixes))
+		for j, prefix := range prefixes {
+			names[j] = prefix + tagName
+		}
+		wrapErr := func(from, to reflect.Type) ScanErr {
+			return newScanErr(t.Name(), sf.Name, from, to)
+		}
+		isJSON := hasTagOption(tag, jsonTagOption)
+		prefix, flatten := lookUpTagOption(tag, "prefix")
+		nestable := !isJSON && isNestable(sf.Type)
+		// a struct field with the prefix option is listed by the columns of its fields instead
+		var column string
+		if inColumns && !(flatten && nestable) {
+			column = columnPrefix + tagName
+		}
+		candidates = append(candidates, planCandidate{planField{fieldIndex, sf.Name, wrapErr, isJSON, nil}, names, depth, mapped, tag, column})
+		st, _, _ := structType(sf.Type)
+		if !nestable || visiting[st] {
+			continue
+		}
+		nested := make([]string, 0, 2*len(prefixes))
+		for _, name := range names {
+			nested = append(nested, name+".")
+		}
+		if flatten {
+			for _, p := range prefixes {
+				nested = append(nested, p+prefix)
+			}
+		}
+		visiting[st] = true
+		candidates = append(candidates, s.collectCandidates(st, fieldIndex, nested, columnPrefix+prefix, inColumns && flatten, depth+1, visiting)...)
+		delete(visiting, st)
+	}
+	return candidates
+}
+
+// Column is a column the fields of a struct are bound to
+type Column struct {
+	Name string
+	// Index is the index path of the field, see reflect.Type.FieldByIndex
+	Index []int
+	// Tag is the whole tag of the field, it's empty for an untagged field mapped by name
+	Tag string
+}
+
+// Field returns the field of struct v the column is bound to, it reports false if there's a nil pointer on the way
+func (c Column) Field(v reflect.Value) (reflect.Value, bool) {
+	return existingField(v, c.Index)
+}
+
+// Columns returns the columns the fields of t, a struct or a pointer to struct, are bound to in the order of the fields.
+// the fields of anonymous embedded structs and of the struct fields with the prefix option are flattened into their columns,
+// a column is left out if a shallower field takes it, the same as Scan does
+func (s *Scanner) Columns(t reflect.Type) ([]Column, error) {
+	st, _, ok := structType(t)
+	if !ok {
+		return nil, ErrNoneStructTarget
+	}
+	if columns, ok := s.columns.Load(st); ok {
+		return columns.([]Column), nil
+	}
+	candidates := s.collectCandidates(st, nil, []string{""}, "", true, 0, map[reflect.Type]bool{})
+	depths := make(map[string]int)
+	for _, c := range candidates {
+		if d, ok := depths[c.column]; "" != c.column && (!ok || c.depth < d) {
+			depths[c.column] = c.depth
+		}
+	}
+	columns := make([]Column, 0, len(candidates))
+	for _, c := range candidates {
+		if "" != c.column && c.depth == depths[c.column] {
+			columns = append(columns, Column{c.column, c.index, c.tag})
+		}
+	}
+	s.columns.Store(st, columns)
+	return columns, nil
+}
+
+// Columns is the same as Default().Columns
+func Columns(t reflect.Type) ([]Column, error) {
+	return Default().Columns(t)
+}
+
+// existingField is reflect.Value.FieldByIndex but reports false for a nil pointer on the way
+func existingField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex but allocates the nil pointers on the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// structType returns the struct type t points to and the number of pointers in between
+func structType(t reflect.Type) (reflect.Type, int, bool) {
+	var ptrs int
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		ptrs++
+	}
+	return t, ptrs, t.Kind() == reflect.Struct
+}
+
+// rowDecoder decodes the rows straight into structs following a fieldPlan.
+// *sql.Rows scans into sql.Scanner destinations converting the values into the fields,
+// other Rows scan into interface{} holders which are converted afterwards
+type rowDecoder struct {
+	s      *Scanner
+	plan   *fieldPlan
+	direct bool
+	dest   []interface{}
+	// the struct the current row is decoded into
+	current reflect.Value
+	// the error of convert met by a fieldScanner
+	err error
+}
+
+// fieldScanner converts the value of a column into the fields of the current struct
+type fieldScanner struct {
+	d   *rowDecoder
+	col int
+}
+
+func (f *fieldScanner) Scan(src interface{}) error {
+	// the driver may reuse the memory of a []byte, the other fields convert it into values of their own
+	if b, ok := src.([]byte); ok && f.d.plan.keepsBytes[f.col] {
+		src = append([]byte(nil), b...)
+	}
+	err := f.d.convertColumn(f.col, src)
+	if nil != err {
+		f.d.err = err
+	}
+	return err
+}
+
+// discardScanner drops the value of a column no field is bound to
+type discardScanner struct{}
+
+func (discardScanner) Scan(interface{}) error {
+	return nil
+}
+
+// newRowDecoder returns a rowDecoder of struct type t, it fails with ErrNameConflict if the plan has a conflict,
+// a strict Scanner fails with ErrUnboundColumn as well
+func (s *Scanner) newRowDecoder(rows Rows, t reflect.Type, columns []string) (*rowDecoder, error) {
+	d := &rowDecoder{
+		s:    s,
+		plan: s.getPlan(t, columns),
+		dest: make([]interface{}, len(columns)),
+	}
+	if "" != d.plan.conflict {
+		return nil, fmt.Errorf("%w: %s", ErrNameConflict, d.plan.conflict)
+	}
+	if s.strict && "" != d.plan.unbound {
+		return nil, fmt.Errorf("%w: %s of %s", ErrUnboundColumn, d.plan.unbound, d.plan.typeName)
+	}
+	_, d.direct = rows.(*sql.Rows)
+	for i := range d.dest {
+		switch {
+		case !d.direct:
+			d.dest[i] = new(interface{})
+		case nil == d.plan.fields[i]:
+			d.dest[i] = discardScanner{}
+		default:
+			d.dest[i] = &fieldScanner{d, i}
+		}
+	}
+	return d, nil
+}
+
+func (d *rowDecoder) convertColumn(col int, src interface{}) error {
+	for _, f := range d.plan.fields[col] {
+		var fv reflect.Value
+		if nil == src {
+			// NULL doesn't allocate, so a nested pointer stays nil without any value
+			var ok bool
+			if fv, ok = existingField(d.current, f.index); !ok {
+				continue
+			}
+		} else {
+			fv = fieldByIndex(d.current, f.index)
+		}
+		var err error
+		if f.json {
+			err = d.s.convertJSON(src, fv, f.conv, f.wrapErr)
+		} else {
+			err = d.s.convert(src, fv, f.conv, f.wrapErr)
+		}
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode scans the current row of rows into v, which must be an addressable struct
+func (d *rowDecoder) decode(rows Rows, v reflect.Value) (resp error) {
+	defer func() {
+		if r := recover(); nil != r {
+			resp = fmt.Errorf("error:[%v], stack:[%s]", r, string(debug.Stack()))
+		}
+	}()
+	d.current = v
+	d.err = nil
+	if err := rows.Scan(d.dest...); nil != err {
+		// database/sql wraps the error of a Scanner, return the one of convert as bind does
+		if nil != d.err {
+			return d.err
+		}
+		return err
+	}
+	if d.direct {
+		return nil
+	}
+	for col, holder := range d.dest {
+		if nil == d.plan.fields[col] {
+			continue
+		}
+		if err := d.convertColumn(col, *(holder.(*interface{}))); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeNew decodes the current row into a new value of type t, which is a struct or a pointer to struct
+func (d *rowDecoder) decodeNew(rows Rows, t reflect.Type) (reflect.Value, error) {
+	st, ptrs, _ := structType(t)
+	obj := reflect.New(st)
+	if err := d.decode(rows, obj.Elem()); nil != err {
+		return reflect.Value{}, err
+	}
+	if 0 == ptrs {
+		return obj.Elem(), nil
+	}
+	for ; ptrs > 1; ptrs-- {
+		p := reflect.New(obj.Type())
+		p.Elem().Set(obj)
+		obj = p
+	}
+	return obj, nil
+}
+
+// scanStructs decodes the rows into target, which is a pointer to a slice of struct or of pointer to struct.
+// target is left untouched if there's no row
+func (s *Scanner) scanStructs(rows Rows, columns []string, target reflect.Value) error {
+	sliceType := target.Type()
+	elemType := sliceType.Elem()
+	st, _, _ := structType(elemType)
+	d, err := s.newRowDecoder(rows, st, columns)
+	if nil != err {
+		return err
+	}
+	var result reflect.Value
+	for rows.Next() {
+		if !result.IsValid() {
+			result = reflect.MakeSlice(sliceType, 0, 8)
+		}
+		obj, err := d.decodeNew(rows, elemType)
+		if nil != err {
+			return err
+		}
+		result = reflect.Append(result, obj)
+	}
+	if err := rowsErr(rows); nil != err {
+		return err
+	}
+	if result.IsValid() {
+		target.Set(result)
+	}
+	return nil
+}
+
+// scanStruct decodes the first row into target, which is a struct or a pointer to struct.
+// a struct is decoded in place so the fields without a column are kept
+func (s *Scanner) scanStruct(rows Rows, columns []string, target reflect.Value) error {
+	if !rows.Next() {
+		if err := rowsErr(rows); nil != err {
+			return err
+		}
+		return ErrEmptyResult
+	}
+	st, ptrs, _ := structType(target.Type())
+	d, err := s.newRowDecoder(rows, st, columns)
+	if nil != err {
+		return err
+	}
+	if 0 == ptrs {
+		return d.decode(rows, target)
+	}
+	obj, err := d.decodeNew(rows, target.Type())
+	if nil != err {
+		return err
+	}
+	target.Set(obj)
+	return nil
+}
@@ -0,0 +1,298 @@
+package scanner
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type planUser struct {
+	ID      int       `ddb:"id"`
+	Name    string    `ddb:"name"`
+	Raw     []byte    `ddb:"raw"`
+	Score   float32   `ddb:"score"`
+	Created time.Time `ddb:"created"`
+	Alias   string    `ddb:"name"`
+	Ignored string
+	private string `ddb:"private"`
+}
+
+func TestGetPlan(t *testing.T) {
+	ass := assert.New(t)
+	typ := reflect.TypeOf(planUser{})
+	columns := []string{"name", "unknown", "id", "private"}
+	plan := Default().getPlan(typ, columns)
+	ass.True(plan == Default().getPlan(typ, []string{"name", "unknown", "id", "private"}))
+	ass.Equal("planUser", plan.typeName)
+	fields := make([][]string, len(plan.fields))
+	for col, fs := range plan.fields {
+		for _, f := range fs {
+			fields[col] = append(fields[col], f.name)
+		}
+	}
+	ass.Equal([][]string{{"Name", "Alias"}, nil, {"ID"}, nil}, fields)
+	ass.Equal([]int{5}, plan.fields[0][1].index)
+	ass.False(plan == Default().getPlan(typ, []string{"name"}))
+}
+
+func TestColumns(t *testing.T) {
+	type author struct {
+		ID   int64  `ddb:"id"`
+		Name string `ddb:"name"`
+	}
+	type base struct {
+		ID      int64 `ddb:"id"`
+		Created int64 `ddb:"created"`
+	}
+	type post struct {
+		base
+		ID       int64             `ddb:"id"`
+		Title    string            `ddb:"title"`
+		Author   author            `ddb:"author,prefix=author_"`
+		Editor   *author           `ddb:"editor,prefix=editor_"`
+		Nested   author            `ddb:"nested"`
+		Attrs    map[string]string `ddb:"attrs,json"`
+		Skipped  string            `ddb:"-"`
+		Untagged string
+	}
+	ass := assert.New(t)
+	columns, err := Columns(reflect.TypeOf(&post{}))
+	ass.NoError(err)
+	var names []string
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	// base.id is shadowed by post.id, a struct field without the prefix option is a column of its own
+	ass.Equal([]string{"created", "id", "title", "author_id", "author_name", "editor_id", "editor_name", "nested", "attrs"}, names)
+	ass.Equal(Column{"author_id", []int{3, 0}, "id"}, columns[3])
+	ass.Equal(Column{"attrs", []int{6}, "attrs,json"}, columns[8])
+
+	p := post{Author: author{ID: 2}}
+	fv, ok := columns[3].Field(reflect.ValueOf(p))
+	ass.True(ok)
+	ass.Equal(int64(2), fv.Interface())
+	_, ok = columns[5].Field(reflect.ValueOf(p))
+	ass.False(ok)
+
+	columns, err = New(WithNameMapper(SnakeCase)).Columns(reflect.TypeOf(post{}))
+	ass.NoError(err)
+	ass.Equal(Column{"untagged", []int{8}, ""}, columns[len(columns)-1])
+	_, err = Columns(reflect.TypeOf(1))
+	ass.Equal(ErrNoneStructTarget, err)
+}
+
+func TestPlanKeepsBytes(t *testing.T) {
+	type record struct {
+		Name  string            `ddb:"name"`
+		Raw   []byte            `ddb:"raw"`
+		Ptr   *[]byte           `ddb:"ptr"`
+		Any   interface{}       `ddb:"any"`
+		Attrs map[string]string `ddb:"attrs,json"`
+		Num   *int64            `ddb:"num"`
+		Tags  []string          `ddb:"tags"`
+	}
+	ass := assert.New(t)
+	columns := []string{"name", "raw", "ptr", "any", "attrs", "num", "tags", "unknown"}
+	plan := Default().getPlan(reflect.TypeOf(record{}), columns)
+	// only the []byte of the fields which could keep it is copied
+	ass.Equal([]bool{false, true, true, true, true, false, false, false}, plan.keepsBytes)
+}
+
+func TestScanDirect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	now := time.Now()
+	columns := []string{"id", "name", "raw", "score", "created", "extra"}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(int64(1), []byte("deen"), []byte("xx"), float32(1.5), now, "e").
+		AddRow(int64(2), nil, nil, nil, nil, nil))
+	rows, err := db.Query("SELECT")
+	ass.NoError(err)
+	var users []*planUser
+	ass.NoError(Scan(rows, &users))
+	ass.NoError(rows.Close())
+	ass.Equal([]*planUser{
+		{ID: 1, Name: "deen", Raw: []byte("xx"), Score: 1.5, Created: now, Alias: "deen"},
+		{ID: 2},
+	}, users)
+
+	// a struct keeps the fields without a column
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("tony"))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	user := planUser{ID: 3, Ignored: "kept"}
+	ass.NoError(Scan(rows, &user))
+	ass.NoError(rows.Close())
+	ass.Equal(planUser{ID: 3, Name: "tony", Alias: "tony", Ignored: "kept"}, user)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("x"))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	err = Scan(rows, &users)
+	ass.Equal(newScanErr("planUser", "ID", reflect.TypeOf(""), reflect.TypeOf(0)), err)
+	ass.NoError(rows.Close())
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+func TestScanMultiPointer(t *testing.T) {
+	ass := assert.New(t)
+	rows := &fakeRows{columns: []string{"id"}, dataset: [][]interface{}{{int64(7)}}}
+	var user **planUser
+	ass.NoError(Scan(rows, &user))
+	ass.Equal(7, (*user).ID)
+}
+
+var benchColumns = []string{"id", "name", "raw", "score", "created"}
+
+func benchDataset(n int) [][]interface{} {
+	dataset := make([][]interface{}, n)
+	now := time.Now()
+	for i := range dataset {
+		dataset[i] = []interface{}{int64(i), []byte("deen"), []byte("raw"), float32(1.5), now}
+	}
+	return dataset
+}
+
+// legacyScan is the map based path Scan took before the plans, it's kept to benchmark against:
+// every row is resolved into a map, then the fields are looked up by their tags and converted one by one
+func legacyScan(s *Scanner, rows Rows, target interface{}) error {
+	data, err := resolveDataFromRows(rows)
+	if nil != err {
+		return err
+	}
+	sliceObj := reflect.ValueOf(target).Elem()
+	typeObj := sliceObj.Type().Elem()
+	arr := reflect.MakeSlice(sliceObj.Type(), 0, len(data))
+	for _, result := range data {
+		valueObj := reflect.New(typeObj).Elem()
+		if err = legacyBind(s, result, valueObj); nil != err {
+			return err
+		}
+		arr = reflect.Append(arr, valueObj)
+	}
+	sliceObj.Set(arr)
+	return nil
+}
+
+func legacyBind(s *Scanner, result map[string]interface{}, valueObj reflect.Value) error {
+	typeObj := valueObj.Type()
+	for i := 0; i < valueObj.NumField(); i++ {
+		fieldTypeI := typeObj.Field(i)
+		fieldName := fieldTypeI.Name
+		wrapErr := func(from, to reflect.Type) ScanErr {
+			return newScanErr(typeObj.Name(), fieldName, from, to)
+		}
+		valuei := valueObj.Field(i)
+		if !valuei.CanSet() {
+			continue
+		}
+		tagName, tag, ok := s.lookUpTagName(fieldTypeI)
+		if !ok || "" == tagName {
+			continue
+		}
+		mapValue, ok := result[tagName]
+		if !ok {
+			continue
+		}
+		// the interfaces of the field were checked for every value
+		tc := s.typeConvOf(fieldTypeI.Type, make(map[reflect.Type]*typeConv))
+		var err error
+		if hasTagOption(tag, jsonTagOption) {
+			err = s.convertJSON(mapValue, valuei, tc, wrapErr)
+		} else {
+			err = s.convert(mapValue, valuei, tc, wrapErr)
+		}
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestLegacyScan(t *testing.T) {
+	ass := assert.New(t)
+	dataset := benchDataset(3)
+	var users, legacy []planUser
+	ass.NoError(Scan(&fakeRows{columns: benchColumns, dataset: dataset}, &users))
+	ass.NoError(legacyScan(Default(), &fakeRows{columns: benchColumns, dataset: dataset}, &legacy))
+	ass.Equal(users, legacy)
+}
+
+func BenchmarkScanPlan(b *testing.B) {
+	dataset := benchDataset(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var users []planUser
+		if err := Scan(&fakeRows{columns: benchColumns, dataset: dataset}, &users); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanPlan_Legacy(b *testing.B) {
+	dataset := benchDataset(100)
+	s := Default()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var users []planUser
+		if err := legacyScan(s, &fakeRows{columns: benchColumns, dataset: dataset}, &users); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchSQLRows scans 100 rows of *sql.Rows with scan in every iteration
+func benchSQLRows(b *testing.B, scan func(rows Rows, users *[]planUser) error) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	dataset := benchDataset(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		mockRows := sqlmock.NewRows(benchColumns)
+		for _, row := range dataset {
+			values := make([]driver.Value, len(row))
+			for j, v := range row {
+				values[j] = v
+			}
+			mockRows.AddRow(values...)
+		}
+		mock.ExpectQuery("SELECT").WillReturnRows(mockRows)
+		b.StartTimer()
+		rows, err := db.Query("SELECT")
+		if nil != err {
+			b.Fatal(err)
+		}
+		var users []planUser
+		if err = scan(rows, &users); nil != err {
+			b.Fatal(err)
+		}
+		rows.Close()
+	}
+}
+
+// BenchmarkScanSQLRows scans *sql.Rows, whose columns are scanned into the fields directly
+func BenchmarkScanSQLRows(b *testing.B) {
+	benchSQLRows(b, func(rows Rows, users *[]planUser) error {
+		return Scan(rows, users)
+	})
+}
+
+func BenchmarkScanSQLRows_Legacy(b *testing.B) {
+	s := Default()
+	benchSQLRows(b, func(rows Rows, users *[]planUser) error {
+		return legacyScan(s, rows, users)
+	})
+}
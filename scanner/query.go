@@ -0,0 +1,50 @@
+package scanner
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Queryer is implemented by *sql.DB, *sql.Tx and *sql.Conn,
+// so Query works the same inside and outside of a transaction
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Query executes the query on q, scans the result into target and closes the rows.
+// target is the same as the one of Scan
+func Query(ctx context.Context, q Queryer, target interface{}, query string, args ...interface{}) error {
+	return Default().Query(ctx, q, target, query, args...)
+}
+
+// Query is the same as the package level Query but follows the settings of s
+func (s *Scanner) Query(ctx context.Context, q Queryer, target interface{}, query string, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if nil != err {
+		return err
+	}
+	defer rows.Close()
+	if err = s.Scan(rows, target); nil != err {
+		return err
+	}
+	return rows.Err()
+}
+
+// QueryMap is the same as Query but returns the result in the form of []map[string]interface{}
+func QueryMap(ctx context.Context, q Queryer, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	return Default().QueryMap(ctx, q, query, args...)
+}
+
+// QueryMap is the same as the package level QueryMap
+func (s *Scanner) QueryMap(ctx context.Context, q Queryer, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if nil != err {
+		return nil, err
+	}
+	defer rows.Close()
+	result, err := s.ScanMap(rows)
+	if nil != err {
+		return nil, err
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	ctx := context.Background()
+	type person struct {
+		Name string `ddb:"name"`
+		Age  int    `ddb:"age"`
+	}
+
+	mock.ExpectQuery("SELECT name,age FROM person").WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "age"}).AddRow("deen", int64(23)))
+	var persons []person
+	ass.NoError(Query(ctx, db, &persons, "SELECT name,age FROM person WHERE age>?", 10))
+	ass.Equal([]person{{"deen", 23}}, persons)
+
+	// inside a transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT name,age FROM person").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "age"}).AddRow("tony", int64(30)))
+	mock.ExpectQuery("SELECT name FROM person").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("tony"))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	ass.NoError(err)
+	var p person
+	ass.NoError(Query(ctx, tx, &p, "SELECT name,age FROM person"))
+	ass.Equal(person{"tony", 30}, p)
+	m, err := QueryMap(ctx, tx, "SELECT name FROM person")
+	ass.NoError(err)
+	ass.Equal([]map[string]interface{}{{"name": "tony"}}, m)
+	ass.NoError(tx.Commit())
+
+	queryErr := errors.New("bad connection")
+	mock.ExpectQuery("SELECT").WillReturnError(queryErr)
+	ass.Equal(queryErr, Query(ctx, db, &persons, "SELECT name FROM person"))
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	ass.Equal(ErrEmptyResult, Query(ctx, db, &p, "SELECT name FROM person"))
+	ass.NoError(mock.ExpectationsWereMet())
+}
@@ -1,13 +1,12 @@
 package scanner
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"time"
-
-	"fmt"
-	"runtime/debug"
 )
 
 // ByteUnmarshaler is the interface implemented by types
@@ -40,7 +39,6 @@ const (
 )
 
 var (
-	userDefinedTagName string
 	//ErrTargetNotSettable means the second param of Bind is not settable
 	ErrTargetNotSettable = errors.New("[scanner]: target is not settable! a pointer is required")
 	//ErrNilRows means the first param can't be a nil
@@ -49,14 +47,22 @@ var (
 	ErrSliceToString = errors.New("[scanner]: can't transmute a non-uint8 slice to string")
 	//ErrEmptyResult occurs when target of Scan isn't slice and the result of the query is empty
 	ErrEmptyResult = errors.New(`[scanner]: empty result`)
+	//ErrTargetNotStruct means the target of Scan is neither a struct nor a slice of struct
+	ErrTargetNotStruct = errors.New("[scanner]: target must be a pointer to a struct or a slice of struct")
+	//ErrUnboundColumn occurs when a strict Scanner meets a column which isn't bound to any field
+	ErrUnboundColumn = errors.New("[scanner]: column isn't bound to any field")
 )
 
-//SetTagName can be set only once
+//SetTagName sets the tag name of the default Scanner, it can be set only once.
+//use New(WithTagName(name)) for a Scanner of your own
 func SetTagName(name string) {
-	if userDefinedTagName != "" {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if tagNameSet {
 		return
 	}
-	userDefinedTagName = name
+	tagNameSet = "" != name
+	defaultScanner.Store(Default().With(WithTagName(name)))
 }
 
 //ScanErr will be returned if an underlying type couldn't be AssignableTo type of target field
@@ -78,39 +84,56 @@ func newScanErr(structName, fieldName string, from, to reflect.Type) ScanErr {
 // When the target is not a pointer of slice, ErrEmptyResult
 // may be returned if the query result is empty
 func Scan(rows Rows, target interface{}) error {
+	return Default().Scan(rows, target)
+}
+
+// Scan is the same as the package level Scan but follows the settings of s
+func (s *Scanner) Scan(rows Rows, target interface{}) error {
 	if nil == target || reflect.ValueOf(target).IsNil() || reflect.TypeOf(target).Kind() != reflect.Ptr {
 		return ErrTargetNotSettable
 	}
 
-	data, err := resolveDataFromRows(rows)
-	if nil != err {
-		return err
+	if nil == rows {
+		return ErrNilRows
 	}
-
-	switch reflect.TypeOf(target).Elem().Kind() {
-	case reflect.Slice:
-		if nil == data {
-			return nil
+	targetObj := reflect.ValueOf(target).Elem()
+	if _, _, ok := structType(targetObj.Type()); ok {
+		columns, err := rows.Columns()
+		if nil != err {
+			return err
 		}
-		err = bindSlice(data, target)
-	default:
-		if nil == data {
-			return ErrEmptyResult
+		return s.scanStruct(rows, columns, targetObj)
+	}
+	if targetObj.Kind() == reflect.Slice {
+		if _, _, ok := structType(targetObj.Type().Elem()); ok {
+			columns, err := rows.Columns()
+			if nil != err {
+				return err
+			}
+			return s.scanStructs(rows, columns, targetObj)
 		}
-		err = bind(data[0], target)
 	}
-
-	return err
+	return ErrTargetNotStruct
 }
 
 // ScanMap returns the result in the form of []map[string]interface{}
 func ScanMap(rows Rows) ([]map[string]interface{}, error) {
+	return Default().ScanMap(rows)
+}
+
+// ScanMap is the same as the package level ScanMap, the values are returned as the driver gives
+func (s *Scanner) ScanMap(rows Rows) ([]map[string]interface{}, error) {
 	return resolveDataFromRows(rows)
 }
 
 // ScanMapClose is the same as ScanMap and close the rows
 func ScanMapClose(rows Rows) ([]map[string]interface{}, error) {
-	result, err := ScanMap(rows)
+	return Default().ScanMapClose(rows)
+}
+
+// ScanMapClose is the same as the package level ScanMapClose
+func (s *Scanner) ScanMapClose(rows Rows) ([]map[string]interface{}, error) {
+	result, err := s.ScanMap(rows)
 	if nil != err {
 		return nil, err
 	}
@@ -121,7 +144,12 @@ func ScanMapClose(rows Rows) ([]map[string]interface{}, error) {
 // ScanClose is the same as Scan and helps you Close the rows
 // Don't exec the rows.Close after calling this
 func ScanClose(rows Rows, target interface{}) error {
-	err := Scan(rows, target)
+	return Default().ScanClose(rows, target)
+}
+
+// ScanClose is the same as the package level ScanClose but follows the settings of s
+func (s *Scanner) ScanClose(rows Rows, target interface{}) error {
+	err := s.Scan(rows, target)
 	if nil != rows {
 		if nil == err {
 			err = rows.Close()
@@ -130,84 +158,6 @@ func ScanClose(rows Rows, target interface{}) error {
 	return err
 }
 
-//caller must guarantee to pass a &slice as the second param
-func bindSlice(arr []map[string]interface{}, target interface{}) error {
-	targetObj := reflect.ValueOf(target)
-	if !targetObj.Elem().CanSet() {
-		return ErrTargetNotSettable
-	}
-	length := len(arr)
-	valueArrObj := reflect.MakeSlice(targetObj.Elem().Type(), 0, length)
-	typeObj := valueArrObj.Type().Elem()
-	var err error
-	for i := 0; i < length; i++ {
-		newObj := reflect.New(typeObj)
-		newObjInterface := newObj.Interface()
-		err = bind(arr[i], newObjInterface)
-		if nil != err {
-			return err
-		}
-		valueArrObj = reflect.Append(valueArrObj, newObj.Elem())
-	}
-	targetObj.Elem().Set(valueArrObj)
-	return nil
-}
-
-func bind(result map[string]interface{}, target interface{}) (resp error) {
-	if nil != resp {
-		return
-	}
-	defer func() {
-		if r := recover(); nil != r {
-			resp = fmt.Errorf("error:[%v], stack:[%s]", r, string(debug.Stack()))
-		}
-	}()
-	valueObj := reflect.ValueOf(target).Elem()
-	if !valueObj.CanSet() {
-		return ErrTargetNotSettable
-	}
-	typeObj := valueObj.Type()
-	if typeObj.Kind() == reflect.Ptr {
-		ptrType := typeObj.Elem()
-		newObj := reflect.New(ptrType)
-		newObjInterface := newObj.Interface()
-		err := bind(result, newObjInterface)
-		if nil == err {
-			valueObj.Set(newObj)
-		}
-		return err
-	}
-	typeObjName := typeObj.Name()
-
-	for i := 0; i < valueObj.NumField(); i++ {
-		fieldTypeI := typeObj.Field(i)
-		fieldName := fieldTypeI.Name
-
-		//for convenience
-		wrapErr := func(from, to reflect.Type) ScanErr {
-			return newScanErr(typeObjName, fieldName, from, to)
-		}
-
-		valuei := valueObj.Field(i)
-		if !valuei.CanSet() {
-			continue
-		}
-		tagName, ok := lookUpTagName(fieldTypeI)
-		if !ok || "" == tagName {
-			continue
-		}
-		mapValue, ok := result[tagName]
-		if !ok {
-			continue
-		}
-		err := convert(mapValue, valuei, wrapErr)
-		if nil != err {
-			return err
-		}
-	}
-	return nil
-}
-
 func isIntSeriesType(k reflect.Kind) bool {
 	return k >= reflect.Int && k <= reflect.Int64
 }
@@ -250,39 +200,68 @@ func resolveDataFromRows(rows Rows) ([]map[string]interface{}, error) {
 	return result, nil
 }
 
-func lookUpTagName(typeObj reflect.StructField) (string, bool) {
-	var tName string
-	if "" != userDefinedTagName {
-		tName = userDefinedTagName
-	} else {
-		tName = DefaultTagName
+// lookUpTagName returns the column name of the field and its whole tag,
+// an untagged field is mapped by mapName
+func (s *Scanner) lookUpTagName(typeObj reflect.StructField) (string, string, bool) {
+	tag, ok := typeObj.Tag.Lookup(s.tagName)
+	if ok {
+		return resolveTagName(tag), tag, true
 	}
-	name, ok := typeObj.Tag.Lookup(tName)
-	if !ok {
-		return "", false
+	if typeObj.Anonymous || "" != typeObj.PkgPath {
+		return "", "", false
 	}
-	name = resolveTagName(name)
-	return name, ok
+	name, ok := s.mapName(typeObj.Name)
+	return name, "", ok
 }
 
-func convert(mapValue interface{}, valuei reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) error {
+func (s *Scanner) convert(mapValue interface{}, valuei reflect.Value, tc *typeConv, wrapErr func(from, to reflect.Type) ScanErr) error {
+	//a registered converter takes care of everything itself
+	if nil != tc.converter {
+		return tc.converter(mapValue, valuei)
+	}
+	//sql.Scanner takes care of everything itself, including NULL
+	if ok, err := tc.convertScanner(mapValue, valuei); ok {
+		return err
+	}
 	//vit: ValueI Type
-	vit := valuei.Type()
+	vit := tc.typ
 	//mvt: MapValue Type
 	mvt := reflect.TypeOf(mapValue)
+	//pointers other than ByteUnmarshaler: NULL sets nil, otherwise the value is converted into a new element
+	if tc.pointer {
+		return s.convertPointer(mapValue, mvt, tc, valuei, wrapErr)
+	}
 	if nil == mvt {
 		return nil
 	}
-	//[]byte tp []byte && time.Time to time.Time
+	//time.Time to time.Time, string or unix seconds
+	if assertT, ok := mapValue.(time.Time); ok {
+		return s.handleConvertTime(mapValue, assertT, mvt, vit, &valuei, wrapErr)
+	}
+	//text to time.Time, time.Duration or a slice(postgres array), the other targets don't need the []byte copied into a string
+	if tc.parsesText {
+		var text string
+		var isText bool
+		switch assertT := mapValue.(type) {
+		case []byte:
+			text, isText = string(assertT), true
+		case string:
+			text, isText = assertT, true
+		}
+		if isText {
+			if ok, err := s.handleConvertTimeText(text, mvt, vit, &valuei, wrapErr); ok {
+				return err
+			}
+			if ok, err := s.handleConvertArray(text, mvt, tc, &valuei, wrapErr); ok {
+				return err
+			}
+		}
+	}
+	//[]byte tp []byte
 	if mvt.AssignableTo(vit) {
 		valuei.Set(reflect.ValueOf(mapValue))
 		return nil
 	}
-	//time.Time to string
-	switch assertT := mapValue.(type) {
-	case time.Time:
-		return handleConvertTime(assertT, mvt, vit, &valuei, wrapErr)
-	}
 
 	//according to go-mysql-driver/mysql, driver.Value type can only be:
 	//int64 or []byte(> maxInt64)
@@ -291,7 +270,10 @@ func convert(mapValue interface{}, valuei reflect.Value, wrapErr func(from, to r
 	//time.Time if parseTime=true or DATE type will be converted into []byte
 	switch mvt.Kind() {
 	case reflect.Int64:
-		if isIntSeriesType(vit.Kind()) {
+		if vit == timeType {
+			//unix seconds
+			valuei.Set(reflect.ValueOf(s.inLocation(time.Unix(mapValue.(int64), 0))))
+		} else if isIntSeriesType(vit.Kind()) {
 			valuei.SetInt(mapValue.(int64))
 		} else if isUintSeriesType(vit.Kind()) {
 			valuei.SetUint(uint64(mapValue.(int64)))
@@ -311,19 +293,122 @@ func convert(mapValue interface{}, valuei reflect.Value, wrapErr func(from, to r
 			return wrapErr(mvt, vit)
 		}
 	case reflect.Slice:
-		return handleConvertSlice(mapValue, mvt, vit, &valuei, wrapErr)
+		err := handleConvertSlice(mapValue, mvt, tc, &valuei, wrapErr)
+		if nil != err && s.handleConvertUnixText(mapValue, vit, &valuei) {
+			return nil
+		}
+		return err
 	default:
 		return wrapErr(mvt, vit)
 	}
 	return nil
 }
 
-func handleConvertSlice(mapValue interface{}, mvt, vit reflect.Type, valuei *reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) error {
+func (s *Scanner) convertPointer(mapValue interface{}, mvt reflect.Type, tc *typeConv, valuei reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) error {
+	if nil == mvt {
+		valuei.Set(reflect.Zero(tc.typ))
+		return nil
+	}
+	if mvt.AssignableTo(tc.typ) {
+		valuei.Set(reflect.ValueOf(mapValue))
+		return nil
+	}
+	elem := reflect.New(tc.elem.typ)
+	if err := s.convert(mapValue, elem.Elem(), tc.elem, wrapErr); nil != err {
+		return err
+	}
+	valuei.Set(elem)
+	return nil
+}
+
+var (
+	sqlScannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	bytesType      = reflect.TypeOf([]byte(nil))
+)
+
+// typeConv is what convert needs to know about the type of a field, it's worked out once per field of a fieldPlan
+// so the interfaces the type implements aren't checked for every value
+type typeConv struct {
+	typ       reflect.Type
+	converter ConvertFunc
+	// the type is a pointer implementing sql.Scanner
+	ptrScanner bool
+	// the pointer to the type implements sql.Scanner
+	addrScanner bool
+	// the type itself implements sql.Scanner, ie: a map type
+	valueScanner bool
+	unmarshaler  bool
+	// a pointer other than ByteUnmarshaler, its element is converted
+	pointer bool
+	// a slice taking a postgres array, see isArrayTarget
+	array bool
+	// time.Time, time.Duration or an array, which are parsed from text
+	parsesText bool
+	// the field could keep a []byte value, so the memory of the driver must be copied first
+	keepsBytes bool
+	// the typeConv of the element of a pointer or an array
+	elem *typeConv
+}
+
+// typeConvOf returns the typeConv of t, built holds the ones already built so a recursive type ends
+func (s *Scanner) typeConvOf(t reflect.Type, built map[reflect.Type]*typeConv) *typeConv {
+	if tc, ok := built[t]; ok {
+		return tc
+	}
+	tc := &typeConv{
+		typ:          t,
+		converter:    s.converters[t],
+		ptrScanner:   t.Kind() == reflect.Ptr && t.Implements(sqlScannerType),
+		addrScanner:  reflect.PtrTo(t).Implements(sqlScannerType),
+		valueScanner: t.Kind() != reflect.Interface && t.Implements(sqlScannerType),
+		unmarshaler:  t.Implements(byteUnmarshalerType),
+		array:        isArrayTarget(t),
+	}
+	tc.pointer = t.Kind() == reflect.Ptr && !tc.unmarshaler
+	tc.parsesText = t == timeType || t == durationType || tc.array
+	tc.keepsBytes = nil != tc.converter || tc.unmarshaler || bytesType.AssignableTo(t)
+	built[t] = tc
+	if tc.pointer || tc.array {
+		tc.elem = s.typeConvOf(t.Elem(), built)
+		tc.keepsBytes = tc.keepsBytes || (tc.pointer && tc.elem.keepsBytes)
+	}
+	return tc
+}
+
+// convertScanner scans mapValue with the sql.Scanner of valuei, or of its address,
+// it reports false if valuei isn't a sql.Scanner
+func (tc *typeConv) convertScanner(mapValue interface{}, valuei reflect.Value) (bool, error) {
+	var sc sql.Scanner
+	switch {
+	case tc.ptrScanner:
+		// NULL is left to the pointer itself
+		if nil == mapValue {
+			return false, nil
+		}
+		if valuei.IsNil() {
+			valuei.Set(reflect.New(tc.typ.Elem()))
+		}
+		sc = valuei.Interface().(sql.Scanner)
+	case tc.addrScanner && valuei.CanAddr():
+		sc = valuei.Addr().Interface().(sql.Scanner)
+	case tc.valueScanner:
+		sc = valuei.Interface().(sql.Scanner)
+	default:
+		return false, nil
+	}
+	if err := sc.Scan(mapValue); nil != err {
+		return true, fmt.Errorf("[scanner]: %s.Scan fail to scan %T, err: %w", tc.typ, mapValue, err)
+	}
+	return true, nil
+}
+
+func handleConvertSlice(mapValue interface{}, mvt reflect.Type, tc *typeConv, valuei *reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) error {
 	mapValueSlice, ok := mapValue.([]byte)
 	if !ok {
 		return ErrSliceToString
 	}
 	mapValueStr := string(mapValueSlice)
+	vit := tc.typ
 	vitKind := vit.Kind()
 	switch {
 	case vitKind == reflect.String:
@@ -347,7 +432,7 @@ func handleConvertSlice(mapValue interface{}, mvt, vit reflect.Type, valuei *ref
 		}
 		valuei.SetFloat(floatVal)
 	default:
-		if _, ok := valuei.Interface().(ByteUnmarshaler); ok {
+		if tc.unmarshaler {
 			var pt reflect.Value
 			initFlag := false
 			// init pointer
@@ -372,11 +457,3 @@ func handleConvertSlice(mapValue interface{}, mvt, vit reflect.Type, valuei *ref
 	return nil
 }
 
-func handleConvertTime(assertT time.Time, mvt, vit reflect.Type, valuei *reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) error {
-	if vit.Kind() == reflect.String {
-		sTime := assertT.Format(cTimeFormat)
-		valuei.SetString(sTime)
-		return nil
-	}
-	return wrapErr(mvt, vit)
-}
@@ -1,10 +1,13 @@
 package scanner
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"time"
@@ -27,7 +30,7 @@ func TestBindOne(t *testing.T) {
 		"name": name,
 		"ag":   age,
 	}
-	err := bind(mp, &p)
+	err := bindMap(mp, &p)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(name, p.Name)
@@ -46,7 +49,7 @@ func TestBindOne_byte_string(t *testing.T) {
 		"name": name,
 		"ag":   age,
 	}
-	err := bind(mp, &p)
+	err := bindMap(mp, &p)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(string(name), p.Name)
@@ -65,7 +68,7 @@ func TestBindOne_byte_uint8(t *testing.T) {
 		"name": name,
 		"ag":   age,
 	}
-	err := bind(mp, &p)
+	err := bindMap(mp, &p)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(name, p.Name)
@@ -84,7 +87,7 @@ func TestBindOne_byte_uint8_pointer(t *testing.T) {
 		"name": name,
 		"ag":   age,
 	}
-	err := bind(mp, p)
+	err := bindMap(mp, p)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(name, p.Name)
@@ -103,7 +106,7 @@ func TestBindOne_uint8_byte(t *testing.T) {
 		"name": name,
 		"ag":   age,
 	}
-	err := bind(mp, &p)
+	err := bindMap(mp, &p)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(name, p.Name)
@@ -119,7 +122,7 @@ func TestBindOne_float(t *testing.T) {
 	var mp = map[string]interface{}{
 		"sl": salary,
 	}
-	err := bind(mp, &p)
+	err := bindMap(mp, &p)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(salary, p.Salary)
@@ -135,7 +138,7 @@ func TestBindSlice(t *testing.T) {
 	for _, v := range testCases {
 		data = append(data, map[string]interface{}{"age": v})
 	}
-	err := bindSlice(data, &students)
+	err := bindMaps(data, &students)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(len(testCases), len(students))
@@ -164,7 +167,7 @@ func Test_Scan_PointerArr(t *testing.T) {
 			"sala": float32(0.0),
 		},
 	)
-	err := bindSlice(data, &stus)
+	err := bindMaps(data, &stus)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(len(data), len(stus))
@@ -179,7 +182,7 @@ func Test_Bind_Float32_2_Float64(t *testing.T) {
 		Num float64 `ddb:"num"`
 	}
 	var a A
-	err := bind(map[string]interface{}{
+	err := bindMap(map[string]interface{}{
 		"num": float32(10.5),
 	}, &a)
 	ass := assert.New(t)
@@ -192,7 +195,7 @@ func Test_Bind_Float64_2_Float32(t *testing.T) {
 		Num float32 `ddb:"num"`
 	}
 	var a A
-	err := bind(map[string]interface{}{
+	err := bindMap(map[string]interface{}{
 		"num": float64(10.5),
 	}, &a)
 	ass := assert.New(t)
@@ -206,7 +209,7 @@ func Test_Bind_int64_2_uint64(t *testing.T) {
 		Age uint8  `ddb:"age"`
 	}
 	var a A
-	err := bind(map[string]interface{}{
+	err := bindMap(map[string]interface{}{
 		"num": int64(10),
 		"age": int64(20),
 	}, &a)
@@ -226,7 +229,7 @@ func Test_Ignore_Unexported_Field(t *testing.T) {
 		"name": []byte("Tommmm"),
 		"age":  int64(100),
 	}
-	err := bind(data, &Tom)
+	err := bindMap(data, &Tom)
 	ass := assert.New(t)
 	ass.NoError(err)
 	ass.Equal(0, Tom.age)
@@ -243,15 +246,22 @@ func Test_Bind_Time_2_String(t *testing.T) {
 	}
 	var tObj Whatever
 	ass := assert.New(t)
-	err := bind(data, &tObj)
+	err := bindMap(data, &tObj)
 	ass.NoError(err, "time.Time should transform to string and bind to string type")
 	ass.Equal(now.Format("2006-01-02 15:04:05"), tObj.When)
+	type Unix struct {
+		When int64 `ddb:"create_time"`
+	}
+	var unix Unix
+	err = bindMap(data, &unix)
+	ass.NoError(err, "time.Time should transform to unix seconds and bind to integer type")
+	ass.Equal(now.Unix(), unix.When)
 	type WillErr struct {
-		When int `ddb:"create_time"`
+		When bool `ddb:"create_time"`
 	}
 	var some WillErr
-	err = bind(data, &some)
-	ass.Error(err, "time.Time could only bind to time.Time&string type %v", some)
+	err = bindMap(data, &some)
+	ass.Error(err, "time.Time could only bind to time.Time&string&integer type %v", some)
 }
 
 func Test_ScanMap(t *testing.T) {
@@ -355,7 +365,7 @@ func Test_Slice_2_Int(t *testing.T) {
 		mp := map[string]interface{}{
 			"age": tc.in,
 		}
-		err := bind(mp, &u)
+		err := bindMap(mp, &u)
 		if tc.err == nil {
 			ass.NoError(err)
 		} else {
@@ -421,7 +431,7 @@ func Test_Slice_2_UInt(t *testing.T) {
 		mp := map[string]interface{}{
 			"age": tc.in,
 		}
-		err := bind(mp, &u)
+		err := bindMap(mp, &u)
 		if tc.err == nil {
 			ass.NoError(err)
 		} else {
@@ -477,7 +487,7 @@ func Test_Slice_2_Float(t *testing.T) {
 		mp := map[string]interface{}{
 			"score": tc.in,
 		}
-		err := bind(mp, &u)
+		err := bindMap(mp, &u)
 		if tc.err == nil {
 			ass.NoError(err)
 		} else {
@@ -528,7 +538,7 @@ func Test_uint8_2_any(t *testing.T) {
 	ass := assert.New(t)
 	for _, tc := range testData {
 		var u user
-		err := bind(tc.in, &u)
+		err := bindMap(tc.in, &u)
 		if tc.err == nil {
 			ass.NoError(err)
 		} else {
@@ -538,14 +548,23 @@ func Test_uint8_2_any(t *testing.T) {
 	}
 }
 
+// resetDefault restores the default Scanner changed by a test
+func resetDefault() {
+	tagNameSet = false
+	defaultScanner.Store(New())
+}
+
 func TestTagSetOnlyOnce(t *testing.T) {
-	userDefinedTagName = "a"
+	defer resetDefault()
+	old := Default()
+	SetTagName("a")
 	SetTagName("foo")
-	assert.Equal(t, "a", userDefinedTagName)
-	userDefinedTagName = ""
+	assert.Equal(t, "a", Default().tagName)
+	assert.Equal(t, DefaultTagName, old.tagName)
+	resetDefault()
+	SetTagName("")
 	SetTagName("foo")
-	assert.Equal(t, "foo", userDefinedTagName)
-
+	assert.Equal(t, "foo", Default().tagName)
 }
 
 type fakeRows struct {
@@ -582,11 +601,41 @@ func (r *fakeRows) Scan(dt ...interface{}) (err error) {
 	}()
 	for i := 0; i < lendt; i++ {
 		data := r.dataset[r.idx][i]
-		reflect.ValueOf(dt[i]).Elem().Set(reflect.ValueOf(data))
+		dest := reflect.ValueOf(dt[i]).Elem()
+		if nil == data {
+			dest.Set(reflect.Zero(dest.Type()))
+			continue
+		}
+		dest.Set(reflect.ValueOf(data))
 	}
 	return nil
 }
 
+// bindMaps scans data, whose maps are keyed by the same columns, into target with the default Scanner.
+// the columns are sorted so the failing one is always met at the same place
+func bindMaps(data []map[string]interface{}, target interface{}) error {
+	rows := &fakeRows{}
+	if len(data) > 0 {
+		for column := range data[0] {
+			rows.columns = append(rows.columns, column)
+		}
+		sort.Strings(rows.columns)
+	}
+	for _, mp := range data {
+		row := make([]interface{}, len(rows.columns))
+		for i, column := range rows.columns {
+			row[i] = mp[column]
+		}
+		rows.dataset = append(rows.dataset, row)
+	}
+	return Scan(rows, target)
+}
+
+// bindMap scans a row of the columns and values of result into target with the default Scanner
+func bindMap(result map[string]interface{}, target interface{}) error {
+	return bindMaps([]map[string]interface{}{result}, target)
+}
+
 func TestScanNotSettable(t *testing.T) {
 	ass := assert.New(t)
 	err := Scan(&fakeRows{}, nil)
@@ -637,7 +686,6 @@ func TestScanMock(t *testing.T) {
 		Age  int    `ddb:"age"`
 	}
 	var boys []curdBoy
-	userDefinedTagName = "ddb"
 	err := Scan(scannn, &boys)
 	ass.NoError(err)
 	ass.Equal("deen", boys[0].Name)
@@ -654,7 +702,6 @@ func TestScanEmpty(t *testing.T) {
 		Age  int    `ddb:"age"`
 	}
 	var boys []curdBoy
-	userDefinedTagName = "ddb"
 	err := Scan(scannn, &boys)
 	ass.NoError(err)
 	ass.Equal(0, len(boys))
@@ -739,8 +786,129 @@ func TestUnmarshalByte(t *testing.T) {
 		if idx >= 2 {
 			student.Extra = &extraInfo{}
 		}
-		err := bind(tc.mapv, &student)
+		err := bindMap(tc.mapv, &student)
 		ass.Equal(tc.err, err, "idx:%d", idx)
 		ass.Equal(tc.expect, student, "idx:%d", idx)
 	}
 }
+
+// upperString is a sql.Scanner
+type upperString string
+
+func (u *upperString) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*u = "NULL"
+	case []byte:
+		*u = upperString(strings.ToUpper(string(v)))
+	case string:
+		*u = upperString(strings.ToUpper(v))
+	default:
+		return fmt.Errorf("unsupported %T", src)
+	}
+	return nil
+}
+
+func TestScanSQLScanner(t *testing.T) {
+	type record struct {
+		Name    sql.NullString  `ddb:"name"`
+		Age     sql.NullInt64   `ddb:"age"`
+		Score   sql.NullFloat64 `ddb:"score"`
+		Created sql.NullTime    `ddb:"created"`
+		Code    upperString     `ddb:"code"`
+		PCode   *upperString    `ddb:"pcode"`
+	}
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	now := time.Now()
+	columns := []string{"name", "age", "score", "created", "code", "pcode"}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow([]byte("deen"), int64(23), 1.5, now, []byte("abc"), "x").
+		AddRow(nil, nil, nil, nil, nil, nil))
+	rows, err := db.Query("SELECT")
+	ass.NoError(err)
+	var records []record
+	ass.NoError(Scan(rows, &records))
+	ass.NoError(rows.Close())
+	x := upperString("X")
+	ass.Equal([]record{
+		{
+			Name:    sql.NullString{String: "deen", Valid: true},
+			Age:     sql.NullInt64{Int64: 23, Valid: true},
+			Score:   sql.NullFloat64{Float64: 1.5, Valid: true},
+			Created: sql.NullTime{Time: now, Valid: true},
+			Code:    "ABC",
+			PCode:   &x,
+		},
+		{Code: "NULL"},
+	}, records)
+
+	// the map path
+	scannn := &fakeRows{columns: []string{"code"}, dataset: [][]interface{}{{"y"}}}
+	var rec record
+	ass.NoError(Scan(scannn, &rec))
+	ass.Equal(upperString("Y"), rec.Code)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow(int64(1)))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	err = Scan(rows, &records)
+	ass.EqualError(err, "[scanner]: scanner.upperString.Scan fail to scan int64, err: unsupported int64")
+	ass.NoError(rows.Close())
+	ass.NoError(mock.ExpectationsWereMet())
+}
+
+func TestScanPointerFields(t *testing.T) {
+	type record struct {
+		ID      *int64     `ddb:"id"`
+		Age     *int       `ddb:"age"`
+		Name    *string    `ddb:"name"`
+		Score   *float64   `ddb:"score"`
+		Created *time.Time `ddb:"created"`
+		Stamp   *string    `ddb:"stamp"`
+		Ext     *extraInfo `ddb:"ext"`
+	}
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	now := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"id", "age", "name", "score", "created", "stamp", "ext"}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(int64(1), []byte("23"), []byte("deen"), 1.5, now, now, []byte(`{"ln":7}`)).
+		AddRow(nil, nil, nil, nil, nil, nil, nil))
+	rows, err := db.Query("SELECT")
+	ass.NoError(err)
+	var records []record
+	ass.NoError(Scan(rows, &records))
+	ass.NoError(rows.Close())
+	ass.Len(records, 2)
+	ass.Equal(int64(1), *records[0].ID)
+	ass.Equal(23, *records[0].Age)
+	ass.Equal("deen", *records[0].Name)
+	ass.Equal(1.5, *records[0].Score)
+	ass.Equal(now, *records[0].Created)
+	ass.Equal("2018-01-02 03:04:05", *records[0].Stamp)
+	ass.Equal(7, records[0].Ext.LuckyNumber)
+	ass.Equal(record{}, records[1])
+
+	// NULL sets nil
+	one := int64(1)
+	rec := record{ID: &one}
+	scannn := &fakeRows{columns: []string{"id"}, dataset: [][]interface{}{{(*int64)(nil)}}}
+	ass.NoError(Scan(scannn, &rec))
+	ass.Nil(rec.ID)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"age"}).AddRow([]byte("x")))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	ass.Equal(newScanErr("record", "Age", reflect.TypeOf([]byte{}), reflect.TypeOf(0)), Scan(rows, &records))
+	ass.NoError(rows.Close())
+	ass.NoError(mock.ExpectationsWereMet())
+}
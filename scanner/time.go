@@ -0,0 +1,252 @@
+package scanner
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeLayouts are the layouts a text column is parsed into time.Time with,
+// they cover the text form of mysql DATETIME/DATE and postgres timestamp/timestamptz/date
+var DefaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	errInterval = errors.New("[scanner]: invalid interval")
+)
+
+func (s *Scanner) inLocation(t time.Time) time.Time {
+	if nil == s.timeLocation {
+		return t
+	}
+	return t.In(s.timeLocation)
+}
+
+// parseTime parses text with the layouts in order
+func (s *Scanner) parseTime(text string) (time.Time, bool) {
+	loc := s.timeLocation
+	if nil == loc {
+		loc = time.UTC
+	}
+	for _, layout := range s.timeLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); nil == err {
+			return s.inLocation(t), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// handleConvertTime binds a time.Time to time.Time, string(formatted) or an integer(unix seconds)
+func (s *Scanner) handleConvertTime(mapValue interface{}, assertT time.Time, mvt, vit reflect.Type, valuei *reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) error {
+	assertT = s.inLocation(assertT)
+	switch {
+	case mvt.AssignableTo(vit) && nil == s.timeLocation:
+		// mapValue is already boxed, boxing assertT again allocates for every row
+		valuei.Set(reflect.ValueOf(mapValue))
+	case mvt.AssignableTo(vit):
+		valuei.Set(reflect.ValueOf(assertT))
+	case vit.Kind() == reflect.String:
+		valuei.SetString(assertT.Format(s.timeFormat))
+	case vit == durationType:
+		return wrapErr(mvt, vit)
+	case isIntSeriesType(vit.Kind()):
+		valuei.SetInt(assertT.Unix())
+	case isUintSeriesType(vit.Kind()):
+		valuei.SetUint(uint64(assertT.Unix()))
+	default:
+		return wrapErr(mvt, vit)
+	}
+	return nil
+}
+
+// handleConvertUnixText binds a text timestamp, which isn't a plain integer, to an integer as unix seconds,
+// it reports false if vit isn't an integer or the text isn't a timestamp
+func (s *Scanner) handleConvertUnixText(mapValue interface{}, vit reflect.Type, valuei *reflect.Value) bool {
+	b, ok := mapValue.([]byte)
+	if !ok || vit == durationType {
+		return false
+	}
+	isInt, isUint := isIntSeriesType(vit.Kind()), isUintSeriesType(vit.Kind())
+	if !isInt && !isUint {
+		return false
+	}
+	t, ok := s.parseTime(string(b))
+	if !ok {
+		return false
+	}
+	if isInt {
+		valuei.SetInt(t.Unix())
+	} else {
+		valuei.SetUint(uint64(t.Unix()))
+	}
+	return true
+}
+
+// handleConvertTimeText binds text to time.Time or time.Duration(a postgres interval),
+// it reports false if vit is neither
+func (s *Scanner) handleConvertTimeText(text string, mvt, vit reflect.Type, valuei *reflect.Value, wrapErr func(from, to reflect.Type) ScanErr) (bool, error) {
+	switch vit {
+	case timeType:
+		t, ok := s.parseTime(text)
+		if !ok {
+			return true, wrapErr(mvt, vit)
+		}
+		valuei.Set(reflect.ValueOf(t))
+		return true, nil
+	case durationType:
+		// a plain integer is nanoseconds as before
+		if i, err := strconv.ParseInt(text, 10, 64); nil == err {
+			valuei.SetInt(i)
+			return true, nil
+		}
+		d, err := parseInterval(text)
+		if nil != err {
+			return true, wrapErr(mvt, vit)
+		}
+		valuei.SetInt(int64(d))
+		return true, nil
+	}
+	return false, nil
+}
+
+// the length of year and month are the same as extract(epoch from interval) of postgres
+const (
+	intervalDay   = 24 * float64(time.Hour)
+	intervalMonth = 30 * intervalDay
+	intervalYear  = 365.25 * intervalDay
+)
+
+var intervalUnits = map[string]float64{
+	"year": intervalYear, "years": intervalYear,
+	"mon": intervalMonth, "mons": intervalMonth, "month": intervalMonth, "months": intervalMonth,
+	"day": intervalDay, "days": intervalDay,
+	"hour": float64(time.Hour), "hours": float64(time.Hour),
+	"min": float64(time.Minute), "mins": float64(time.Minute), "minute": float64(time.Minute), "minutes": float64(time.Minute),
+	"sec": float64(time.Second), "secs": float64(time.Second), "second": float64(time.Second), "seconds": float64(time.Second),
+}
+
+// parseInterval parses the text form of a postgres interval in any intervalstyle(postgres, postgres_verbose, iso_8601),
+// ie: "1 year 2 mons 3 days 04:05:06.5", "@ 1 day 2 hours ago", "P1Y2M3DT4H5M6.5S", "-01:02:03".
+// a Go duration like "1h2m" is accepted as well
+func parseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if "" == s {
+		return 0, errInterval
+	}
+	if strings.HasPrefix(s, "P") || strings.HasPrefix(s, "-P") {
+		return parseISOInterval(s)
+	}
+	if d, err := time.ParseDuration(s); nil == err {
+		return d, nil
+	}
+	var total float64
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case "@" == field:
+			continue
+		case "ago" == field:
+			total = -total
+			continue
+		case strings.Contains(field, ":"):
+			d, err := parseClock(field)
+			if nil != err {
+				return 0, err
+			}
+			total += d
+			continue
+		}
+		n, err := strconv.ParseFloat(field, 64)
+		if nil != err || i+1 == len(fields) {
+			return 0, errInterval
+		}
+		i++
+		unit, ok := intervalUnits[fields[i]]
+		if !ok {
+			return 0, errInterval
+		}
+		total += n * unit
+	}
+	return toDuration(total)
+}
+
+// parseClock parses [-]hh:mm[:ss[.fraction]]
+func parseClock(s string) (float64, error) {
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errInterval
+	}
+	units := []float64{float64(time.Hour), float64(time.Minute), float64(time.Second)}
+	var total float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if nil != err || n < 0 {
+			return 0, errInterval
+		}
+		total += n * units[i]
+	}
+	return sign * total, nil
+}
+
+// parseISOInterval parses the ISO 8601 format with designators, ie: P1Y2M3DT4H5M6.5S
+func parseISOInterval(s string) (time.Duration, error) {
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	}
+	s = s[1:]
+	if "" == s {
+		return 0, errInterval
+	}
+	var total float64
+	dateUnits := map[byte]float64{'Y': intervalYear, 'M': intervalMonth, 'W': 7 * intervalDay, 'D': intervalDay}
+	timeUnits := map[byte]float64{'H': float64(time.Hour), 'M': float64(time.Minute), 'S': float64(time.Second)}
+	units := dateUnits
+	for len(s) > 0 {
+		if 'T' == s[0] {
+			units, s = timeUnits, s[1:]
+			continue
+		}
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+		})
+		if end <= 0 {
+			return 0, errInterval
+		}
+		n, err := strconv.ParseFloat(s[:end], 64)
+		if nil != err {
+			return 0, errInterval
+		}
+		unit, ok := units[s[end]]
+		if !ok {
+			return 0, errInterval
+		}
+		total += n * unit
+		s = s[end+1:]
+	}
+	return toDuration(sign * total)
+}
+
+func toDuration(ns float64) (time.Duration, error) {
+	if ns > math.MaxInt64 || ns < math.MinInt64 {
+		return 0, errInterval
+	}
+	return time.Duration(math.Round(ns)), nil
+}
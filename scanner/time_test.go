@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParseInterval(t *testing.T) {
+	var data = []struct {
+		in  string
+		out time.Duration
+		err error
+	}{
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second, nil},
+		{"-00:00:01.5", -1500 * time.Millisecond, nil},
+		{"1 day 02:00:00", 26 * time.Hour, nil},
+		{"3 days", 72 * time.Hour, nil},
+		{"1 mon -1 days", 29 * 24 * time.Hour, nil},
+		{"1 year", time.Duration(365.25 * 24 * float64(time.Hour)), nil},
+		{"@ 1 day 2 hours 3 mins 4.5 secs ago", -(26*time.Hour + 3*time.Minute + 4500*time.Millisecond), nil},
+		{"P1DT2H3M4.5S", 26*time.Hour + 3*time.Minute + 4500*time.Millisecond, nil},
+		{"-P1W", -7 * 24 * time.Hour, nil},
+		{"PT-5M", -5 * time.Minute, nil},
+		{"1h30m", 90 * time.Minute, nil},
+		{"", 0, errInterval},
+		{"P", 0, errInterval},
+		{"P1X", 0, errInterval},
+		{"1 fortnight", 0, errInterval},
+		{"3", 0, errInterval},
+		{"1:2:3:4", 0, errInterval},
+		{"100000000 years", 0, errInterval},
+	}
+	ass := assert.New(t)
+	for _, tc := range data {
+		d, err := parseInterval(tc.in)
+		ass.Equal(tc.err, err, tc.in)
+		ass.Equal(tc.out, d, tc.in)
+	}
+}
+
+func TestScanTime(t *testing.T) {
+	type record struct {
+		Created  time.Time     `ddb:"created"`
+		Day      time.Time     `ddb:"day"`
+		Updated  *time.Time    `ddb:"updated"`
+		Unix     int64         `ddb:"unix"`
+		FromUnix time.Time     `ddb:"from_unix"`
+		Elapsed  time.Duration `ddb:"elapsed"`
+		Str      string        `ddb:"str"`
+	}
+	defer resetDefault()
+	db, mock, err := sqlmock.New()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ass := assert.New(t)
+	shanghai := time.FixedZone("CST", 8*3600)
+	created := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"created", "day", "updated", "unix", "from_unix", "elapsed", "str"}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow([]byte("2018-01-02 11:04:05.123+08"), []byte("2018-01-02"), "2018-01-02T03:04:05Z",
+			created, int64(1514862245), []byte("1 day 00:00:01"), created))
+	rows, err := db.Query("SELECT")
+	ass.NoError(err)
+	var rec record
+	ass.NoError(Scan(rows, &rec))
+	ass.NoError(rows.Close())
+	ass.True(created.Add(123 * time.Millisecond).Equal(rec.Created))
+	ass.Equal(time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC), rec.Day)
+	ass.True(created.Equal(*rec.Updated))
+	ass.Equal(created.Unix(), rec.Unix)
+	ass.True(created.Equal(rec.FromUnix))
+	ass.Equal(24*time.Hour+time.Second, rec.Elapsed)
+	ass.Equal("2018-01-02 03:04:05", rec.Str)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"unix", "elapsed"}).
+		AddRow([]byte("2018-01-02 03:04:05"), []byte("1514862245")))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	rec = record{}
+	ass.NoError(Scan(rows, &rec))
+	ass.NoError(rows.Close())
+	ass.Equal(created.Unix(), rec.Unix)
+	ass.Equal(time.Duration(1514862245), rec.Elapsed)
+
+	type plainInt struct {
+		Unix int64 `ddb:"unix"`
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"unix"}).AddRow([]byte("1514862245")))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	var pi plainInt
+	ass.NoError(Scan(rows, &pi))
+	ass.NoError(rows.Close())
+	ass.Equal(int64(1514862245), pi.Unix)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"unix"}).AddRow([]byte("yesterday")))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	ass.Equal(newScanErr("plainInt", "Unix", reflect.TypeOf([]byte{}), reflect.TypeOf(int64(0))), Scan(rows, &pi))
+	ass.NoError(rows.Close())
+
+	SetDefault(New(WithTimeLocation(shanghai), WithTimeFormat(time.RFC3339), WithTimeLayouts("2006/01/02 15:04")))
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"created", "day", "str"}).
+		AddRow(created, []byte("2018/01/02 11:04"), created))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	rec = record{}
+	ass.NoError(Scan(rows, &rec))
+	ass.NoError(rows.Close())
+	ass.Equal(shanghai, rec.Created.Location())
+	ass.True(created.Equal(rec.Created))
+	// the text without a zone is in the location
+	ass.True(time.Date(2018, 1, 2, 11, 4, 0, 0, shanghai).Equal(rec.Day))
+	ass.Equal("2018-01-02T11:04:05+08:00", rec.Str)
+
+	// a text timestamp into an integer is unix seconds, a plain integer is kept as is
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"unix"}).AddRow([]byte("2018/01/02 11:04")))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	rec = record{}
+	ass.NoError(Scan(rows, &rec))
+	ass.NoError(rows.Close())
+	ass.Equal(time.Date(2018, 1, 2, 11, 4, 0, 0, shanghai).Unix(), rec.Unix)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"day"}).AddRow([]byte("2018-01-02")))
+	rows, err = db.Query("SELECT")
+	ass.NoError(err)
+	ass.Equal(newScanErr("record", "Day", reflect.TypeOf([]byte{}), reflect.TypeOf(time.Time{})), Scan(rows, &rec))
+	ass.NoError(rows.Close())
+	ass.NoError(mock.ExpectationsWereMet())
+}